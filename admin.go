@@ -0,0 +1,107 @@
+package sink
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// AdminHandler returns the [http.Handler] of the admin API used to inspect and operate the
+// sinker while it runs, use [WithAdminServer] to serve it on its own address or mount it on
+// an existing HTTP server. The API has no authentication, it must not be exposed publicly.
+//
+// Routes, all returning JSON:
+//
+//	GET  /status                    runtime state, see [SinkerStatus]
+//	GET  /config                    effective configuration, see [Sinker.EffectiveConfig]
+//	POST /pause                     see [Sinker.Pause]
+//	POST /resume                    see [Sinker.Resume]
+//	POST /reconnect                 see [Sinker.Reconnect]
+//	POST /stop-at-block?block=<num> see [Sinker.StopAtBlock]
+func (s *Sinker) AdminHandler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/status", adminRoute(http.MethodGet, func(r *http.Request) (any, error) {
+		return s.Status(), nil
+	}))
+
+	mux.HandleFunc("/config", adminRoute(http.MethodGet, func(r *http.Request) (any, error) {
+		return s.EffectiveConfig(), nil
+	}))
+
+	mux.HandleFunc("/pause", adminRoute(http.MethodPost, func(r *http.Request) (any, error) {
+		return map[string]bool{"paused": true, "changed": s.Pause()}, nil
+	}))
+
+	mux.HandleFunc("/resume", adminRoute(http.MethodPost, func(r *http.Request) (any, error) {
+		return map[string]bool{"paused": false, "changed": s.Resume()}, nil
+	}))
+
+	mux.HandleFunc("/reconnect", adminRoute(http.MethodPost, func(r *http.Request) (any, error) {
+		s.Reconnect()
+		return map[string]bool{"reconnecting": true}, nil
+	}))
+
+	mux.HandleFunc("/stop-at-block", adminRoute(http.MethodPost, func(r *http.Request) (any, error) {
+		block, err := strconv.ParseUint(r.URL.Query().Get("block"), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid block query parameter: %w", err)
+		}
+
+		s.StopAtBlock(block)
+		return map[string]uint64{"stop_at_block": block}, nil
+	}))
+
+	return mux
+}
+
+// adminRoute adapts `handler` to an [http.HandlerFunc] accepting only `method`, the returned
+// value is written as JSON and errors are reported as bad requests.
+func adminRoute(method string, handler func(r *http.Request) (any, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method {
+			w.Header().Set("Allow", method)
+			writeAdminJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": fmt.Sprintf("method %s not allowed", r.Method)})
+			return
+		}
+
+		out, err := handler(r)
+		if err != nil {
+			writeAdminJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+
+		writeAdminJSON(w, http.StatusOK, out)
+	}
+}
+
+func writeAdminJSON(w http.ResponseWriter, status int, out any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(out)
+}
+
+// startAdminServer serves [Sinker.AdminHandler] on `listenAddr` until the sinker terminates.
+func (s *Sinker) startAdminServer(listenAddr string) error {
+	listener, err := net.Listen("tcp", listenAddr)
+	if err != nil {
+		return fmt.Errorf("listen on %q: %w", listenAddr, err)
+	}
+
+	server := &http.Server{Handler: s.AdminHandler(), ReadHeaderTimeout: 10 * time.Second}
+	s.OnTerminating(func(_ error) { server.Close() })
+
+	s.logger.Info("admin server listening", zap.Stringer("addr", listener.Addr()))
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			s.logger.Warn("admin server failed", zap.Error(err))
+		}
+	}()
+
+	return nil
+}
@@ -0,0 +1,129 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSinker_AdminHandler(t *testing.T) {
+	sinker := newTestSinker(t, WithExtraHeaders([]string{"x-api-key: secret"}))
+	server := httptest.NewServer(sinker.AdminHandler())
+	defer server.Close()
+
+	call := func(method string, path string, out any) int {
+		request, err := http.NewRequest(method, server.URL+path, nil)
+		require.NoError(t, err)
+
+		response, err := http.DefaultClient.Do(request)
+		require.NoError(t, err)
+		defer response.Body.Close()
+
+		if out != nil {
+			require.NoError(t, json.NewDecoder(response.Body).Decode(out))
+		}
+
+		return response.StatusCode
+	}
+
+	var status SinkerStatus
+	assert.Equal(t, http.StatusOK, call(http.MethodGet, "/status", &status))
+	assert.Equal(t, "localhost:9000", status.Endpoint)
+	assert.Equal(t, sinker.OutputModuleHash(), status.OutputModuleHash)
+	assert.False(t, status.Paused)
+
+	var config SinkerConfig
+	assert.Equal(t, http.StatusOK, call(http.MethodGet, "/config", &config))
+	assert.Equal(t, []string{"x-api-key: <redacted>"}, config.Headers)
+
+	var changed map[string]bool
+	assert.Equal(t, http.StatusOK, call(http.MethodPost, "/pause", &changed))
+	assert.Equal(t, map[string]bool{"paused": true, "changed": true}, changed)
+	assert.Equal(t, http.StatusOK, call(http.MethodGet, "/status", &status))
+	assert.True(t, status.Paused)
+	assert.NotNil(t, status.PausedSince)
+
+	assert.Equal(t, http.StatusOK, call(http.MethodPost, "/resume", &changed))
+	assert.Equal(t, map[string]bool{"paused": false, "changed": true}, changed)
+
+	assert.Equal(t, http.StatusMethodNotAllowed, call(http.MethodGet, "/pause", nil))
+	assert.Equal(t, http.StatusBadRequest, call(http.MethodPost, "/stop-at-block?block=abc", nil))
+	assert.Equal(t, http.StatusOK, call(http.MethodPost, "/stop-at-block?block=2", nil))
+	assert.Equal(t, uint64(2), sinker.Status().StopAtBlock)
+}
+
+func TestSinker_StopAtBlock(t *testing.T) {
+	sinker := newTestSinker(t)
+	handler := NewSinkerHandlers(
+		func(ctx context.Context, data *pbsubstreamsrpc.BlockScopedData, isLive *bool, cursor *Cursor) error {
+			return nil
+		},
+		func(ctx context.Context, undoSignal *pbsubstreamsrpc.BlockUndoSignal, cursor *Cursor) error {
+			return nil
+		},
+	)
+
+	sinker.StopAtBlock(2)
+
+	stream := &fakeBlocksClient{eof: true, messages: []*pbsubstreamsrpc.Response{testDataResponse(1), testDataResponse(2), testDataResponse(3)}}
+	_, _, err := sinker.doRequest(context.Background(), nil, &pbsubstreamsrpc.Request{}, &fakeStreamClient{stream}, nil, handler)
+	require.ErrorIs(t, err, errStopBlockReached)
+
+	status := sinker.Status()
+	assert.Equal(t, &BlockStatus{Number: 2, ID: "2a"}, status.LastHandledBlock)
+	assert.Equal(t, &BlockStatus{Number: 2, ID: "2a"}, status.LastReceivedBlock)
+	assert.NotNil(t, status.Live, "liveness checker configured by default")
+}
+
+func TestSinker_Reconnect(t *testing.T) {
+	sinker := newTestSinker(t)
+	handled := make(chan struct{}, 1)
+	handler := NewSinkerHandlers(
+		func(ctx context.Context, data *pbsubstreamsrpc.BlockScopedData, isLive *bool, cursor *Cursor) error {
+			handled <- struct{}{}
+			return nil
+		},
+		func(ctx context.Context, undoSignal *pbsubstreamsrpc.BlockUndoSignal, cursor *Cursor) error {
+			return nil
+		},
+	)
+
+	done := make(chan error)
+	go func() {
+		stream := &fakeBlocksClient{messages: []*pbsubstreamsrpc.Response{testDataResponse(1)}}
+		_, _, err := sinker.doRequest(context.Background(), nil, &pbsubstreamsrpc.Request{}, &fakeStreamClient{stream}, nil, handler)
+		done <- err
+	}()
+
+	<-handled
+	sinker.Reconnect()
+
+	select {
+	case err := <-done:
+		require.ErrorIs(t, err, errForcedReconnect)
+	case <-time.After(time.Second):
+		t.Fatal("stream not closed")
+	}
+}
+
+func TestSinker_RetryState(t *testing.T) {
+	sinker := newTestSinker(t)
+
+	sinker.recordRetry(errors.New("unavailable"), time.Second)
+	sinker.recordRetry(errors.New("still unavailable"), backoff.Stop)
+
+	state := sinker.RetryState()
+	assert.Equal(t, 2, state.Attempts)
+	assert.Equal(t, "still unavailable", state.LastError)
+	assert.NotNil(t, state.LastErrorAt)
+	assert.Nil(t, state.NextAttemptAt)
+}
@@ -0,0 +1,295 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/streamingfast/substreams/client"
+	"google.golang.org/grpc/metadata"
+)
+
+// DefaultAuthURL is the auth service used by [APIKeyAuthProvider] to exchange an API key
+// for a JWT when no URL is configured.
+const DefaultAuthURL = "https://auth.streamingfast.io/v1/auth/issue"
+
+// AuthProvider provides the credentials used to authenticate against the Substreams endpoint.
+//
+// The [Sinker] consults it each time it (re-)connects to the Substreams endpoint, so credentials
+// can change over the lifetime of the [Sinker]. When the endpoint rejects the credentials with
+// an `Unauthenticated` error, [AuthProvider.Refresh] is called and the request is retried once
+// before giving up.
+type AuthProvider interface {
+	// Credentials returns the token to authenticate with along its type, a [client.JWT] token
+	// is sent as `authorization: Bearer <token>` and a [client.ApiKey] as `x-api-key: <token>`.
+	Credentials(ctx context.Context) (token string, authType client.AuthType, err error)
+
+	// Refresh discards any cached credentials so that the next call to [AuthProvider.Credentials]
+	// obtains fresh ones, it's called when the Substreams endpoint rejected the credentials.
+	Refresh(ctx context.Context) error
+}
+
+// StaticAuthProvider is an [AuthProvider] always returning the same token.
+type StaticAuthProvider struct {
+	token    string
+	authType client.AuthType
+}
+
+func NewStaticAuthProvider(token string, authType client.AuthType) *StaticAuthProvider {
+	return &StaticAuthProvider{token: token, authType: authType}
+}
+
+func (p *StaticAuthProvider) Credentials(ctx context.Context) (string, client.AuthType, error) {
+	return p.token, p.authType, nil
+}
+
+// Refresh is a no-op, the token cannot change.
+func (p *StaticAuthProvider) Refresh(ctx context.Context) error {
+	return nil
+}
+
+func (p *StaticAuthProvider) String() string {
+	return "static"
+}
+
+// FileAuthProvider is an [AuthProvider] reading the token from a file, the file is read
+// again each time it changes (based on its modification time and size), which makes it
+// suitable for tokens rotated by an external process (Kubernetes secrets, Vault agent, etc.).
+// Surrounding whitespaces are trimmed from the file's content.
+type FileAuthProvider struct {
+	path     string
+	authType client.AuthType
+
+	mu      sync.Mutex
+	token   string
+	modTime time.Time
+	size    int64
+}
+
+func NewFileAuthProvider(path string, authType client.AuthType) *FileAuthProvider {
+	return &FileAuthProvider{path: path, authType: authType}
+}
+
+func (p *FileAuthProvider) Credentials(ctx context.Context) (string, client.AuthType, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stat, err := os.Stat(p.path)
+	if err != nil {
+		return "", p.authType, fmt.Errorf("stat token file %q: %w", p.path, err)
+	}
+
+	if p.token != "" && stat.ModTime().Equal(p.modTime) && stat.Size() == p.size {
+		return p.token, p.authType, nil
+	}
+
+	content, err := os.ReadFile(p.path)
+	if err != nil {
+		return "", p.authType, fmt.Errorf("read token file %q: %w", p.path, err)
+	}
+
+	token := strings.TrimSpace(string(content))
+	if token == "" {
+		return "", p.authType, fmt.Errorf("token file %q is empty", p.path)
+	}
+
+	p.token, p.modTime, p.size = token, stat.ModTime(), stat.Size()
+	return p.token, p.authType, nil
+}
+
+// Refresh forces the token file to be read again on next [FileAuthProvider.Credentials] call.
+func (p *FileAuthProvider) Refresh(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.token = ""
+	return nil
+}
+
+func (p *FileAuthProvider) String() string {
+	return fmt.Sprintf("file (%s)", p.path)
+}
+
+// APIKeyAuthProvider is an [AuthProvider] exchanging an API key for a JWT against an auth
+// service. The auth service receives a `POST` request with JSON body `{"api_key": "<key>"}`
+// and must respond with JSON body `{"token": "<jwt>", "expires_at": <unix seconds>}`.
+//
+// The JWT is cached and exchanged again one minute before it expires, or on
+// [APIKeyAuthProvider.Refresh]. When the response has no `expires_at`, the JWT is cached
+// until refreshed.
+type APIKeyAuthProvider struct {
+	apiKey     string
+	url        string
+	httpClient *http.Client
+	now        func() time.Time
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+// NewAPIKeyAuthProvider creates an [APIKeyAuthProvider] exchanging `apiKey` against `authURL`,
+// [DefaultAuthURL] is used if `authURL` is empty.
+func NewAPIKeyAuthProvider(apiKey string, authURL string) *APIKeyAuthProvider {
+	if authURL == "" {
+		authURL = DefaultAuthURL
+	}
+
+	return &APIKeyAuthProvider{
+		apiKey:     apiKey,
+		url:        authURL,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		now:        time.Now,
+	}
+}
+
+// apiKeyExpirationMargin is how long before its expiration a JWT is exchanged again
+const apiKeyExpirationMargin = 1 * time.Minute
+
+func (p *APIKeyAuthProvider) Credentials(ctx context.Context) (string, client.AuthType, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.token != "" && (p.expiresAt.IsZero() || p.now().Before(p.expiresAt.Add(-apiKeyExpirationMargin))) {
+		return p.token, client.JWT, nil
+	}
+
+	token, expiresAt, err := p.exchange(ctx)
+	if err != nil {
+		return "", client.JWT, fmt.Errorf("exchange api key against %q: %w", p.url, err)
+	}
+
+	p.token, p.expiresAt = token, expiresAt
+	return p.token, client.JWT, nil
+}
+
+func (p *APIKeyAuthProvider) exchange(ctx context.Context) (token string, expiresAt time.Time, err error) {
+	body, err := json.Marshal(map[string]string{"api_key": p.apiKey})
+	if err != nil {
+		return "", expiresAt, fmt.Errorf("marshal request: %w", err)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return "", expiresAt, fmt.Errorf("new request: %w", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := p.httpClient.Do(request)
+	if err != nil {
+		return "", expiresAt, err
+	}
+	defer response.Body.Close()
+
+	content, err := io.ReadAll(io.LimitReader(response.Body, 64*1024))
+	if err != nil {
+		return "", expiresAt, fmt.Errorf("read response: %w", err)
+	}
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return "", expiresAt, fmt.Errorf("unexpected status %s: %s", response.Status, strings.TrimSpace(string(content)))
+	}
+
+	var out struct {
+		Token     string `json:"token"`
+		ExpiresAt int64  `json:"expires_at"`
+	}
+	if err := json.Unmarshal(content, &out); err != nil {
+		return "", expiresAt, fmt.Errorf("decode response: %w", err)
+	}
+
+	if out.Token == "" {
+		return "", expiresAt, errors.New("response has no token")
+	}
+
+	if out.ExpiresAt > 0 {
+		expiresAt = time.Unix(out.ExpiresAt, 0)
+	}
+
+	return out.Token, expiresAt, nil
+}
+
+// Refresh discards the cached JWT, the API key is exchanged again on next
+// [APIKeyAuthProvider.Credentials] call.
+func (p *APIKeyAuthProvider) Refresh(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.token = ""
+	return nil
+}
+
+func (p *APIKeyAuthProvider) String() string {
+	return fmt.Sprintf("api key exchange (%s)", p.url)
+}
+
+// CommandAuthProvider is an [AuthProvider] running a credential helper command, the token is
+// the command's standard output with surrounding whitespaces trimmed. The command is run on
+// each [CommandAuthProvider.Credentials] call, caching is left to the helper.
+type CommandAuthProvider struct {
+	command  string
+	args     []string
+	authType client.AuthType
+}
+
+func NewCommandAuthProvider(authType client.AuthType, command string, args ...string) *CommandAuthProvider {
+	return &CommandAuthProvider{command: command, args: args, authType: authType}
+}
+
+func (p *CommandAuthProvider) Credentials(ctx context.Context) (string, client.AuthType, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, p.command, p.args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return "", p.authType, fmt.Errorf("run credential helper %q: %w: %s", p.command, err, message)
+		}
+
+		return "", p.authType, fmt.Errorf("run credential helper %q: %w", p.command, err)
+	}
+
+	token := strings.TrimSpace(stdout.String())
+	if token == "" {
+		return "", p.authType, fmt.Errorf("credential helper %q printed no token", p.command)
+	}
+
+	return token, p.authType, nil
+}
+
+// Refresh is a no-op, the command is run again on each [CommandAuthProvider.Credentials] call.
+func (p *CommandAuthProvider) Refresh(ctx context.Context) error {
+	return nil
+}
+
+func (p *CommandAuthProvider) String() string {
+	return fmt.Sprintf("command (%s)", p.command)
+}
+
+// appendAuthCredentials appends the credentials of `provider` to the outgoing metadata of `ctx`.
+func appendAuthCredentials(ctx context.Context, provider AuthProvider) (context.Context, error) {
+	token, authType, err := provider.Credentials(ctx)
+	if err != nil {
+		return ctx, err
+	}
+
+	switch authType {
+	case client.JWT:
+		return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token), nil
+	case client.ApiKey:
+		return metadata.AppendToOutgoingContext(ctx, client.ApiKeyHeader, token), nil
+	}
+
+	return ctx, nil
+}
@@ -0,0 +1,135 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/streamingfast/substreams/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestFileAuthProvider(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "token")
+
+	provider := NewFileAuthProvider(path, client.JWT)
+
+	_, _, err := provider.Credentials(ctx)
+	require.Error(t, err)
+
+	require.NoError(t, os.WriteFile(path, []byte("first\n"), 0600))
+	token, authType, err := provider.Credentials(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "first", token)
+	assert.Equal(t, client.JWT, authType)
+
+	require.NoError(t, os.WriteFile(path, []byte("second-token\n"), 0600))
+	token, _, err = provider.Credentials(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "second-token", token)
+
+	require.NoError(t, os.WriteFile(path, []byte("  \n"), 0600))
+	_, _, err = provider.Credentials(ctx)
+	require.EqualError(t, err, fmt.Sprintf("token file %q is empty", path))
+}
+
+func TestAPIKeyAuthProvider(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1_700_000_000, 0)
+
+	exchangeCount := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var in struct {
+			APIKey string `json:"api_key"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&in))
+
+		if in.APIKey != "key" {
+			http.Error(w, "invalid api key", http.StatusUnauthorized)
+			return
+		}
+
+		exchangeCount++
+		json.NewEncoder(w).Encode(map[string]any{
+			"token":      fmt.Sprintf("jwt-%d", exchangeCount),
+			"expires_at": now.Add(time.Hour).Unix(),
+		})
+	}))
+	defer server.Close()
+
+	provider := NewAPIKeyAuthProvider("key", server.URL)
+	provider.now = func() time.Time { return now }
+
+	credentials := func() string {
+		token, authType, err := provider.Credentials(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, client.JWT, authType)
+
+		return token
+	}
+
+	assert.Equal(t, "jwt-1", credentials())
+	assert.Equal(t, "jwt-1", credentials(), "cached until expiration")
+
+	require.NoError(t, provider.Refresh(ctx))
+	assert.Equal(t, "jwt-2", credentials(), "exchanged again after refresh")
+
+	now = now.Add(time.Hour - 30*time.Second)
+	assert.Equal(t, "jwt-3", credentials(), "exchanged again when about to expire")
+
+	_, _, err := NewAPIKeyAuthProvider("wrong", server.URL).Credentials(ctx)
+	require.ErrorContains(t, err, "unexpected status 401 Unauthorized: invalid api key")
+}
+
+func TestCommandAuthProvider(t *testing.T) {
+	ctx := context.Background()
+
+	token, authType, err := NewCommandAuthProvider(client.ApiKey, "echo", "  token ").Credentials(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "token", token)
+	assert.Equal(t, client.ApiKey, authType)
+
+	_, _, err = NewCommandAuthProvider(client.JWT, "sh", "-c", "echo failure >&2; exit 1").Credentials(ctx)
+	require.ErrorContains(t, err, "exit status 1: failure")
+
+	_, _, err = NewCommandAuthProvider(client.JWT, "true").Credentials(ctx)
+	require.EqualError(t, err, `credential helper "true" printed no token`)
+}
+
+func Test_appendAuthCredentials(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider AuthProvider
+		expected metadata.MD
+	}{
+		{"jwt", NewStaticAuthProvider("abc", client.JWT), metadata.Pairs("authorization", "Bearer abc")},
+		{"api key", NewStaticAuthProvider("abc", client.ApiKey), metadata.Pairs(client.ApiKeyHeader, "abc")},
+		{"none", NewStaticAuthProvider("abc", client.None), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, err := appendAuthCredentials(context.Background(), tt.provider)
+			require.NoError(t, err)
+
+			md, _ := metadata.FromOutgoingContext(ctx)
+			assert.Equal(t, tt.expected, md)
+		})
+	}
+}
+
+func Test_isUnauthenticated(t *testing.T) {
+	assert.True(t, isUnauthenticated(fmt.Errorf("stream failure: %w", status.Error(codes.Unauthenticated, "expired"))))
+	assert.False(t, isUnauthenticated(status.Error(codes.Unavailable, "down")))
+	assert.False(t, isUnauthenticated(fmt.Errorf("plain")))
+}
@@ -0,0 +1,167 @@
+package sink
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/streamingfast/bstream"
+)
+
+// BlockRange is a block range as requested by the user. Unlike [bstream.Range], its
+// start block can be relative to the chain's head block (a negative start block), in
+// which case the actual start block is only known once the Substreams backend resolved
+// it.
+//
+// The end block is always exclusive and absolute, a nil end block means the range is
+// open and streams forever.
+type BlockRange struct {
+	startBlock int64
+	endBlock   *uint64
+}
+
+// NewBlockRange creates a [BlockRange] starting at `startBlock`, which is relative to
+// the chain's head block when negative, up to `exclusiveEndBlock` which can be nil to
+// stream forever.
+func NewBlockRange(startBlock int64, exclusiveEndBlock *uint64) *BlockRange {
+	return &BlockRange{
+		startBlock: startBlock,
+		endBlock:   exclusiveEndBlock,
+	}
+}
+
+// NewBlockRangeFromRange creates a [BlockRange] out of an absolute [bstream.Range], its
+// end block is considered exclusive.
+func NewBlockRangeFromRange(in *bstream.Range) *BlockRange {
+	return &BlockRange{
+		startBlock: int64(in.StartBlock()),
+		endBlock:   in.EndBlock(),
+	}
+}
+
+// StartBlock returns the requested start block, a negative value means it's relative
+// to the chain's head block.
+func (r *BlockRange) StartBlock() int64 {
+	return r.startBlock
+}
+
+// EndBlock returns the exclusive end block, nil if the range is open.
+func (r *BlockRange) EndBlock() *uint64 {
+	return r.endBlock
+}
+
+// IsHeadRelative returns true if the start block is relative to the chain's head block.
+func (r *BlockRange) IsHeadRelative() bool {
+	return r.startBlock < 0
+}
+
+// Range returns the absolute [bstream.Range] equivalent to this block range, nil if
+// this block range is relative to the chain's head block, use [BlockRange.Resolve] in
+// that case.
+func (r *BlockRange) Range() *bstream.Range {
+	if r.IsHeadRelative() {
+		return nil
+	}
+
+	return r.Resolve(uint64(r.startBlock))
+}
+
+// Resolve returns the absolute [bstream.Range] starting at `startBlock`, which is
+// usually the start block resolved by the Substreams backend, and ending at this
+// block range end block.
+func (r *BlockRange) Resolve(startBlock uint64) *bstream.Range {
+	if r.endBlock == nil {
+		return bstream.NewOpenRange(startBlock)
+	}
+
+	return bstream.NewRangeExcludingEnd(startBlock, *r.endBlock)
+}
+
+// expression returns the block range in the syntax accepted by [ReadBlockRanges].
+func (r *BlockRange) expression() string {
+	if r.endBlock == nil {
+		return fmt.Sprintf("%d:", r.startBlock)
+	}
+
+	return fmt.Sprintf("%d:%d", r.startBlock, *r.endBlock)
+}
+
+func (r *BlockRange) String() string {
+	if r == nil {
+		return "None"
+	}
+
+	if !r.IsHeadRelative() {
+		return r.Range().String()
+	}
+
+	// Mimics [bstream.Range.String] formatting
+	if r.endBlock == nil {
+		return fmt.Sprintf("[head%d, nil]", r.startBlock)
+	}
+
+	return fmt.Sprintf("[head%d, %d)", r.startBlock, *r.endBlock)
+}
+
+// BlockRanges is an ordered list of disjoint [BlockRange] segments, the [Sinker] processes
+// each segment sequentially.
+type BlockRanges []*BlockRange
+
+// Validate ensures segments are ordered and non-overlapping and that only the last segment
+// is open ended or has a start block relative to the chain's head block.
+func (r BlockRanges) Validate() error {
+	if len(r) == 0 {
+		return fmt.Errorf("at least one block range segment is required")
+	}
+
+	for i, segment := range r {
+		if segment == nil {
+			return fmt.Errorf("segment #%d is nil", i)
+		}
+
+		if i == len(r)-1 {
+			break
+		}
+
+		if segment.IsHeadRelative() {
+			return fmt.Errorf("segment %s: only the last segment can have a start block relative to chain's head block", segment)
+		}
+
+		if segment.EndBlock() == nil {
+			return fmt.Errorf("segment %s: only the last segment can be open ended", segment)
+		}
+
+		next := r[i+1]
+		if !next.IsHeadRelative() && uint64(next.StartBlock()) < *segment.EndBlock() {
+			return fmt.Errorf("segment %s: must end at or before next segment %s start block", segment, next)
+		}
+	}
+
+	return nil
+}
+
+// singleRange returns the absolute [bstream.Range] of a single segment block range, erroring
+// if there are multiple segments or if the start block is relative to the chain's head block.
+func (r BlockRanges) singleRange() (*bstream.Range, error) {
+	if len(r) != 1 {
+		return nil, fmt.Errorf("block range %s has %d segments, only a single segment is accepted here", r, len(r))
+	}
+
+	if r[0].IsHeadRelative() {
+		return nil, fmt.Errorf("block range %s is relative to chain's head block, only an absolute block range is accepted here", r[0])
+	}
+
+	return r[0].Range(), nil
+}
+
+func (r BlockRanges) String() string {
+	if len(r) == 0 {
+		return "None"
+	}
+
+	segments := make([]string, len(r))
+	for i, segment := range r {
+		segments[i] = segment.String()
+	}
+
+	return strings.Join(segments, ", ")
+}
@@ -0,0 +1,109 @@
+package sink
+
+import (
+	"context"
+	"testing"
+
+	"github.com/streamingfast/bstream"
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockRange(t *testing.T) {
+	end := uint64(200)
+
+	tests := []struct {
+		name             string
+		blockRange       *BlockRange
+		wantString       string
+		wantHeadRelative bool
+		wantRange        *bstream.Range
+		wantResolved     *bstream.Range
+	}{
+		{"open", NewBlockRange(10, nil), "[10, nil]", false, bstream.NewOpenRange(10), bstream.NewOpenRange(15)},
+		{"closed", NewBlockRange(10, &end), "[10, 200)", false, bstream.NewRangeExcludingEnd(10, 200), bstream.NewRangeExcludingEnd(15, 200)},
+		{"head relative open", NewBlockRange(-1000, nil), "[head-1000, nil]", true, nil, bstream.NewOpenRange(15)},
+		{"head relative closed", NewBlockRange(-1000, &end), "[head-1000, 200)", true, nil, bstream.NewRangeExcludingEnd(15, 200)},
+		{"from range", NewBlockRangeFromRange(bstream.NewRangeExcludingEnd(10, 200)), "[10, 200)", false, bstream.NewRangeExcludingEnd(10, 200), bstream.NewRangeExcludingEnd(15, 200)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantString, tt.blockRange.String())
+			assert.Equal(t, tt.wantHeadRelative, tt.blockRange.IsHeadRelative())
+			assert.Equal(t, tt.wantRange, tt.blockRange.Range())
+			assert.Equal(t, tt.wantResolved, tt.blockRange.Resolve(15))
+		})
+	}
+}
+
+func TestSinker_BlockRangeSegmentsWithBuffer(t *testing.T) {
+	endpoint := newTestStreamServer(t, func(req *pbsubstreamsrpc.Request, stream pbsubstreamsrpc.Stream_BlocksServer) error {
+		start := uint64(req.StartBlockNum)
+		if req.StartCursor != "" {
+			cursor, err := bstream.CursorFromOpaque(req.StartCursor)
+			if err != nil {
+				return err
+			}
+
+			start = cursor.Block.Num() + 1
+		}
+
+		for num := start; num < req.StopBlockNum; num++ {
+			if err := stream.Send(testCursorDataResponse(num)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	var handled []uint64
+	var segmentCursors []uint64
+	handler := &testSegmentHandler{
+		SinkerHandler: NewSinkerHandlers(
+			func(ctx context.Context, data *pbsubstreamsrpc.BlockScopedData, isLive *bool, cursor *Cursor) error {
+				handled = append(handled, data.Clock.Number)
+				return nil
+			},
+			func(ctx context.Context, undoSignal *pbsubstreamsrpc.BlockUndoSignal, cursor *Cursor) error {
+				return nil
+			},
+		),
+		segmentCompleted: func(segment *bstream.Range, cursor *Cursor) {
+			segmentCursors = append(segmentCursors, cursor.Block().Num())
+		},
+	}
+
+	// Adjacent segments and a gap, each segment streams 3 blocks past its end to fill the buffer
+	ends := []uint64{20, 30, 45}
+	sinker := newTestPlaintextSinker(t, endpoint, WithBlockDataBuffer(3), WithRequestedBlockRanges(
+		NewBlockRange(10, &ends[0]),
+		NewBlockRange(20, &ends[1]),
+		NewBlockRange(40, &ends[2]),
+	))
+
+	result := sinker.RunWithResult(context.Background(), nil, handler)
+	require.NoError(t, result.Err)
+
+	var expected []uint64
+	for _, blockRange := range [][2]uint64{{10, 30}, {40, 45}} {
+		for num := blockRange[0]; num < blockRange[1]; num++ {
+			expected = append(expected, num)
+		}
+	}
+
+	assert.Equal(t, expected, handled)
+	assert.Equal(t, []uint64{19, 29, 44}, segmentCursors)
+}
+
+type testSegmentHandler struct {
+	SinkerHandler
+
+	segmentCompleted func(segment *bstream.Range, cursor *Cursor)
+}
+
+func (h *testSegmentHandler) HandleBlockRangeSegmentCompletion(ctx context.Context, segment *bstream.Range, cursor *Cursor) error {
+	h.segmentCompleted(segment, cursor)
+	return nil
+}
@@ -0,0 +1,176 @@
+package sink
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/streamingfast/substreams/client"
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// BlockTimeResolver resolves a point in time to a block number, it's used by
+// [ReadBlockRangesWithTimeResolver] to turn date and time based block range
+// boundaries into actual block numbers.
+type BlockTimeResolver interface {
+	// BlockAtTime returns the number of the first block whose timestamp is equal
+	// or after `at`.
+	BlockAtTime(ctx context.Context, at time.Time) (uint64, error)
+}
+
+// SubstreamsBlockTimeResolver is a [BlockTimeResolver] that binary searches the
+// block at a given time by streaming single blocks from a Substreams endpoint.
+//
+// The search is bounded by the module's initial block and the chain's current
+// head block, so resolving a time that is after the chain's head block is an
+// error.
+type SubstreamsBlockTimeResolver struct {
+	clientConfig *client.SubstreamsClientConfig
+	tlsConfig    *TLSConfig
+	pkg          *pbsubstreams.Package
+	module       *pbsubstreams.Module
+	logger       *zap.Logger
+}
+
+// NewSubstreamsBlockTimeResolver creates a [SubstreamsBlockTimeResolver] that
+// uses `clientConfig` to connect to the Substreams endpoint. The `pkg` and `module`
+// are used to craft the requests, the cheapest module (one that depends only on
+// the chain's source block) of `pkg` starting at or before `module` is used
+// if one exists, `module` itself otherwise.
+func NewSubstreamsBlockTimeResolver(
+	clientConfig *client.SubstreamsClientConfig,
+	pkg *pbsubstreams.Package,
+	module *pbsubstreams.Module,
+	logger *zap.Logger,
+) *SubstreamsBlockTimeResolver {
+	return &SubstreamsBlockTimeResolver{
+		clientConfig: clientConfig,
+		pkg:          pkg,
+		module:       pickBlockTimeModule(pkg, module),
+		logger:       logger,
+	}
+}
+
+// WithTLSConfig customizes the TLS settings used to connect to the Substreams endpoint, see
+// [WithTLSConfig].
+func (r *SubstreamsBlockTimeResolver) WithTLSConfig(config *TLSConfig) *SubstreamsBlockTimeResolver {
+	r.tlsConfig = config
+	return r
+}
+
+func (r *SubstreamsBlockTimeResolver) BlockAtTime(ctx context.Context, at time.Time) (uint64, error) {
+	ssClient, closeFunc, callOpts, headers, err := newSubstreamsClient(r.clientConfig, r.tlsConfig)
+	if err != nil {
+		return 0, fmt.Errorf("new substreams client: %w", err)
+	}
+	defer closeFunc()
+
+	if headers.IsSet() {
+		ctx = metadata.AppendToOutgoingContext(ctx, headers.ToArray()...)
+	}
+
+	clockAt := func(startBlock int64) (*pbsubstreams.Clock, error) {
+		return r.firstClockFrom(ctx, ssClient, callOpts, startBlock)
+	}
+
+	lowClock, err := clockAt(int64(r.module.InitialBlock))
+	if err != nil {
+		return 0, fmt.Errorf("fetch module initial block #%d: %w", r.module.InitialBlock, err)
+	}
+
+	if !lowClock.Timestamp.AsTime().Before(at) {
+		r.logger.Debug("requested time is before module's initial block", zap.Time("at", at), zap.Uint64("block", lowClock.Number))
+		return lowClock.Number, nil
+	}
+
+	// A negative start block is resolved by the Substreams backend relative to the chain's head block
+	highClock, err := clockAt(-1)
+	if err != nil {
+		return 0, fmt.Errorf("fetch head block: %w", err)
+	}
+
+	if highClock.Timestamp.AsTime().Before(at) {
+		return 0, fmt.Errorf("time %s is after chain's head block #%d (%s)", at.Format(time.RFC3339), highClock.Number, highClock.Timestamp.AsTime().Format(time.RFC3339))
+	}
+
+	// Invariant is that the first block at or after `low` is strictly before `at` while the
+	// first block at or after `high` is equal or after `at`. Querying by "first block at or after"
+	// makes the search resilient to chains that skip block numbers.
+	low, high := lowClock.Number, highClock.Number
+	for high-low > 1 {
+		mid := low + (high-low)/2
+
+		clock, err := clockAt(int64(mid))
+		if err != nil {
+			return 0, fmt.Errorf("fetch block #%d: %w", mid, err)
+		}
+
+		if clock.Timestamp.AsTime().Before(at) {
+			low = mid
+		} else {
+			high = mid
+			highClock = clock
+		}
+	}
+
+	r.logger.Debug("resolved block at time", zap.Time("at", at), zap.Uint64("block", highClock.Number), zap.Time("block_time", highClock.Timestamp.AsTime()))
+	return highClock.Number, nil
+}
+
+func (r *SubstreamsBlockTimeResolver) firstClockFrom(ctx context.Context, ssClient pbsubstreamsrpc.StreamClient, callOpts []grpc.CallOption, startBlock int64) (*pbsubstreams.Clock, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := ssClient.Blocks(ctx, &pbsubstreamsrpc.Request{
+		StartBlockNum: startBlock,
+		Modules:       r.pkg.Modules,
+		OutputModule:  r.module.Name,
+	}, callOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("call sf.substreams.rpc.v2.Stream/Blocks: %w", err)
+	}
+
+	for {
+		resp, err := stream.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, fmt.Errorf("stream ended before receiving any block")
+			}
+
+			return nil, err
+		}
+
+		if data := resp.GetBlockScopedData(); data != nil && data.Clock != nil {
+			return data.Clock, nil
+		}
+	}
+}
+
+// pickBlockTimeModule returns the first mapper module of the package that depends only
+// on the chain's source (and params) and that starts at or before `module`, such module
+// is cheap to execute for a single block. If none is found, `module` is returned.
+func pickBlockTimeModule(pkg *pbsubstreams.Package, module *pbsubstreams.Module) *pbsubstreams.Module {
+	if pkg == nil || pkg.Modules == nil {
+		return module
+	}
+
+	for _, candidate := range pkg.Modules.Modules {
+		if candidate.GetKindMap() == nil || candidate.InitialBlock > module.InitialBlock {
+			continue
+		}
+
+		if every(candidate.Inputs, func(input *pbsubstreams.Module_Input) bool {
+			return input.GetSource() != nil || input.GetParams() != nil
+		}) {
+			return candidate
+		}
+	}
+
+	return module
+}
@@ -41,7 +41,7 @@ func (b *blockDataBuffer) HandleBlockScopedData(blockData *pbsubstreamsrpc.Block
 	if b.dataEmptyAt != 0 {
 		highestBlock := b.data[b.dataEmptyAt-1]
 		if blockData.Clock.Number <= highestBlock.Clock.Number {
-			return nil, fmt.Errorf("received new block scoped data (Block %s) whose height is lower or equal than our most recent block (Block %s)", blockToRef(blockData), blockToRef(highestBlock))
+			return nil, &BufferError{Block: blockToRef(blockData), ConflictingBlock: blockToRef(highestBlock)}
 		}
 	}
 
@@ -90,7 +90,7 @@ func (b *blockDataBuffer) HandleBlockUndoSignal(undoSignal *pbsubstreamsrpc.Bloc
 		// We might have actually sent exactly the last valid block, in which case no error should occur since the chain
 		// ordering is respected
 		if !bstream.EqualsBlockRefs(b.lastEmittedBlock, lastValidBlock) {
-			return fmt.Errorf("cannot undo down to last valid Block %s because we already sent you Block %s which is after last valid block", lastValidBlock, b.lastEmittedBlock)
+			return &BufferError{Undo: true, Block: lastValidBlock, ConflictingBlock: b.lastEmittedBlock}
 		}
 	}
 
@@ -129,6 +129,22 @@ func (b *blockDataBuffer) findOldestFinalBlockIndex(finalHeight uint64) int {
 	return oldestFinalBlockAt
 }
 
+// Summary returns a summary of the blocks currently buffered, it must be called from the
+// goroutine using the buffer.
+func (b *blockDataBuffer) Summary() *BufferSummary {
+	summary := &BufferSummary{Capacity: len(b.data), Length: b.dataEmptyAt}
+	if b.dataEmptyAt > 0 {
+		summary.OldestBlock = newBlockStatus(blockToRef(b.data[0]))
+		summary.NewestBlock = newBlockStatus(blockToRef(b.data[b.dataEmptyAt-1]))
+	}
+
+	if b.lastEmittedBlock != nil {
+		summary.LastEmittedBlock = newBlockStatus(b.lastEmittedBlock)
+	}
+
+	return summary
+}
+
 func (b *blockDataBuffer) Capacity() int {
 	return len(b.data)
 }
@@ -0,0 +1,731 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Clock field paths usable in a [ColumnarColumn], they refer to the block the row comes from.
+const (
+	ColumnarBlockNumber    = "@block_number"
+	ColumnarBlockID        = "@block_id"
+	ColumnarBlockTimestamp = "@block_timestamp"
+)
+
+const (
+	columnarUncommittedSuffix = ".partial"
+	columnarManifestFile      = "manifest.json"
+)
+
+// ColumnarColumn maps a column of the rows written by a [ColumnarHandler] to a field path, fields
+// separated by `.`, relative to the row message. Repeated, map and message fields are written
+// as JSON. See [ColumnarBlockNumber], [ColumnarBlockID] and [ColumnarBlockTimestamp] for the
+// block columns.
+type ColumnarColumn struct {
+	Name      string `json:"name"`
+	FieldPath string `json:"field_path"`
+}
+
+// ColumnarFormat is the file format written by a [ColumnarHandler], only [ColumnarFormatCSV] is
+// built in, other formats like Parquet are implemented on top of the library of your choice.
+type ColumnarFormat interface {
+	// Name is the format name recorded in the manifest.
+	Name() string
+
+	// Extension is the file extension, including the leading `.`.
+	Extension() string
+
+	// NewWriter returns a writer of rows with `columns` into `out`.
+	NewWriter(out io.Writer, columns []string) (ColumnarWriter, error)
+}
+
+// ColumnarWriter writes the rows of a bundle file, see [ColumnarFormat].
+type ColumnarWriter interface {
+	// Write writes a row, values are nil (unset message field), bool, int64, uint64, float64,
+	// string, []byte or time.Time.
+	Write(row []any) error
+
+	// Close flushes the rows written, the underlying file is closed by the caller.
+	Close() error
+}
+
+// ColumnarFormatCSV writes CSV files with a header row, bytes are written hex encoded and
+// timestamps in RFC 3339 format.
+var ColumnarFormatCSV ColumnarFormat = csvColumnarFormat{}
+
+// ColumnarOption configures a [ColumnarHandler].
+type ColumnarOption func(h *ColumnarHandler)
+
+// WithColumnarBundleSize sets the number of blocks of each file, a file holds the rows of the
+// blocks of a single `[k*bundleSize, (k+1)*bundleSize)` range. Defaults to 1000.
+func WithColumnarBundleSize(bundleSize uint64) ColumnarOption {
+	return func(h *ColumnarHandler) {
+		h.bundleSize = bundleSize
+	}
+}
+
+// WithColumnarRowsPath sets the field path, relative to the output message, of the repeated
+// field whose elements are written as rows. Repeated fields along the path are all flattened,
+// `blocks.transactions` writes one row per transaction of each block. Defaults to the output
+// message itself, written as a single row per block.
+func WithColumnarRowsPath(fieldPath string) ColumnarOption {
+	return func(h *ColumnarHandler) {
+		h.rowsPath = fieldPath
+	}
+}
+
+// WithColumnarFormat sets the file format, defaults to [ColumnarFormatCSV].
+func WithColumnarFormat(format ColumnarFormat) ColumnarOption {
+	return func(h *ColumnarHandler) {
+		h.format = format
+	}
+}
+
+// ColumnarHandler is a ready-made [SinkerHandler] for analytics backfills, flattening the
+// output decoded using the Protobuf descriptors embedded in the package into rows written to one
+// file per bundle of blocks in a local directory.
+//
+// It only supports final blocks, see [WithFinalBlocksOnly]. A bundle is written to an
+// uncommitted `<first block><ext>.partial` file, committed once the stream moved past it by
+// renaming it to `<first block>-<end block><ext>`, the end block being excluded. Each commit
+// rewrites the `manifest.json` file listing the files committed along with the cursor to restart
+// from, see [ColumnarHandler.LoadCursor]. When the block range completes, or the sinker stops
+// gracefully, the last bundle is committed even if incomplete, its end block being then the one
+// after its last block, and the manifest is marked completed on block range completion.
+type ColumnarHandler struct {
+	sinker     *Sinker
+	directory  string
+	columns    []ColumnarColumn
+	bundleSize uint64
+	rowsPath   string
+	format     ColumnarFormat
+
+	plans   map[protoreflect.FullName]*columnarPlan
+	current *columnarBundle
+	files   []ColumnarManifestFile
+	cursor  *Cursor
+}
+
+// ColumnarManifest is the content of the `manifest.json` file written by a [ColumnarHandler].
+type ColumnarManifest struct {
+	OutputModule string                 `json:"output_module"`
+	ModuleHash   string                 `json:"module_hash"`
+	Format       string                 `json:"format"`
+	BundleSize   uint64                 `json:"bundle_size"`
+	RowsPath     string                 `json:"rows_path"`
+	Columns      []ColumnarColumn       `json:"columns"`
+	Cursor       string                 `json:"cursor"`
+	Completed    bool                   `json:"completed"`
+	Files        []ColumnarManifestFile `json:"files"`
+}
+
+// ColumnarManifestFile describes a file of a [ColumnarManifest], the bundle covers the blocks
+// from StartBlock, the first block written, up to, but excluding, EndBlock.
+type ColumnarManifestFile struct {
+	Path       string `json:"path"`
+	StartBlock uint64 `json:"start_block"`
+	EndBlock   uint64 `json:"end_block"`
+	Blocks     uint64 `json:"blocks"`
+	Rows       uint64 `json:"rows"`
+}
+
+type columnarBundle struct {
+	ColumnarManifestFile
+
+	lastBlock  uint64
+	lastCursor *Cursor
+	file       *os.File
+	writer     ColumnarWriter
+}
+
+// columnarPlan holds the fields of the rows path and of each column resolved for a message type.
+type columnarPlan struct {
+	rows    []protoreflect.FieldDescriptor
+	columns [][]protoreflect.FieldDescriptor
+}
+
+// NewColumnarHandler returns a [ColumnarHandler] writing `columns` into `directory`, created if
+// missing. `sinker` must stream final blocks only.
+func NewColumnarHandler(sinker *Sinker, directory string, columns []ColumnarColumn, opts ...ColumnarOption) (*ColumnarHandler, error) {
+	if !sinker.finalBlocksOnly {
+		return nil, errors.New("columnar handler requires the sinker to stream final blocks only, see WithFinalBlocksOnly")
+	}
+
+	if len(columns) == 0 {
+		return nil, errors.New("at least one column is required")
+	}
+
+	h := &ColumnarHandler{
+		sinker:     sinker,
+		directory:  directory,
+		columns:    columns,
+		bundleSize: 1000,
+		format:     ColumnarFormatCSV,
+		plans:      map[protoreflect.FullName]*columnarPlan{},
+		cursor:     NewBlankCursor(),
+	}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	if h.bundleSize == 0 {
+		return nil, errors.New("bundle size must be greater than 0")
+	}
+
+	if err := os.MkdirAll(directory, 0o755); err != nil {
+		return nil, fmt.Errorf("create output directory: %w", err)
+	}
+
+	return h, nil
+}
+
+// LoadCursor returns the cursor to restart from, the one of the last block committed, read from
+// the manifest of a previous run. Files left uncommitted, or committed but not yet listed in the
+// manifest, by a previous run are removed.
+//
+// An error matching [ErrModuleHashChanged] is returned if the files were produced by a module
+// with a different hash than the one streamed by the sinker, and an error is returned if they
+// were produced with a different format, rows path or columns.
+func (h *ColumnarHandler) LoadCursor(_ context.Context) (*Cursor, error) {
+	cursor := NewBlankCursor()
+
+	content, err := os.ReadFile(filepath.Join(h.directory, columnarManifestFile))
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, fmt.Errorf("read manifest: %w", err)
+	default:
+		var manifest ColumnarManifest
+		if err := json.Unmarshal(content, &manifest); err != nil {
+			return nil, fmt.Errorf("decode manifest: %w", err)
+		}
+
+		if manifest.ModuleHash != h.sinker.OutputModuleHash() {
+			return nil, &ModuleHashChangedError{StoredHash: manifest.ModuleHash, CurrentHash: h.sinker.OutputModuleHash()}
+		}
+
+		if manifest.Format != h.format.Name() || manifest.RowsPath != h.rowsPath || !slices.Equal(manifest.Columns, h.columns) {
+			return nil, errors.New("output directory was produced with a different format, rows path or columns")
+		}
+
+		if cursor, err = NewCursor(manifest.Cursor); err != nil {
+			return nil, fmt.Errorf("manifest cursor: %w", err)
+		}
+
+		h.files = manifest.Files
+	}
+
+	listed := make(map[string]bool, len(h.files))
+	for _, file := range h.files {
+		listed[file.Path] = true
+	}
+
+	entries, err := os.ReadDir(h.directory)
+	if err != nil {
+		return nil, fmt.Errorf("list output directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+
+		var first, end uint64
+		_, scanErr := fmt.Sscanf(name, "%d-%d"+h.format.Extension(), &first, &end)
+		committed := scanErr == nil && name == fmt.Sprintf("%010d-%010d%s", first, end, h.format.Extension())
+
+		if (committed && !listed[name]) || strings.HasSuffix(name, h.format.Extension()+columnarUncommittedSuffix) {
+			h.sinker.logger.Info("removing file written after the manifest cursor", zap.String("file", name), zap.Stringer("cursor", cursor))
+			if err := os.Remove(filepath.Join(h.directory, name)); err != nil {
+				return nil, fmt.Errorf("remove stale file: %w", err)
+			}
+		}
+	}
+
+	h.cursor = cursor
+	return cursor, nil
+}
+
+func (h *ColumnarHandler) HandleBlockScopedData(_ context.Context, data *pbsubstreamsrpc.BlockScopedData, _ *bool, cursor *Cursor) error {
+	message, err := h.sinker.DecodeOutput(data)
+	if err != nil {
+		return fmt.Errorf("decode output: %w", err)
+	}
+
+	plan, err := h.plan(message.Descriptor())
+	if err != nil {
+		return err
+	}
+
+	bundle, err := h.bundle(data.Clock.GetNumber())
+	if err != nil {
+		return err
+	}
+
+	for _, row := range flattenColumnarRows(message, plan.rows) {
+		values := make([]any, len(h.columns))
+		for i, column := range h.columns {
+			values[i] = columnarValue(data.Clock, row, column.FieldPath, plan.columns[i])
+		}
+
+		if err := bundle.writer.Write(values); err != nil {
+			return fmt.Errorf("write row: %w", err)
+		}
+		bundle.Rows++
+	}
+
+	bundle.Blocks++
+	bundle.lastBlock = data.Clock.GetNumber()
+	bundle.lastCursor = cursor
+	return nil
+}
+
+func (h *ColumnarHandler) HandleBlockUndoSignal(_ context.Context, _ *pbsubstreamsrpc.BlockUndoSignal, _ *Cursor) error {
+	return errors.New("columnar handler does not support undo signals, the sinker must stream final blocks only")
+}
+
+// HandleFlush commits the current bundle, even if incomplete, so that a restart resumes after
+// the last block handled.
+func (h *ColumnarHandler) HandleFlush(_ context.Context, _ *Cursor) error {
+	if err := h.commit(true); err != nil {
+		return err
+	}
+
+	return h.writeManifest(false)
+}
+
+// HandleBlockRangeCompletion commits the last bundle, even if incomplete, and marks the
+// manifest completed.
+func (h *ColumnarHandler) HandleBlockRangeCompletion(_ context.Context, cursor *Cursor) error {
+	if err := h.commit(true); err != nil {
+		return err
+	}
+
+	// Blocks without rows might follow the last bundle, the range end is the cursor to restart from
+	h.cursor = cursor
+	return h.writeManifest(true)
+}
+
+// Close closes the current bundle file without committing it, a restart removes it.
+func (h *ColumnarHandler) Close() error {
+	if h.current == nil {
+		return nil
+	}
+
+	bundle := h.current
+	h.current = nil
+
+	return bundle.close()
+}
+
+// bundle returns the bundle `blockNum` belongs to, committing the current one if the stream
+// moved past it.
+func (h *ColumnarHandler) bundle(blockNum uint64) (*columnarBundle, error) {
+	if h.current != nil {
+		if blockNum < h.current.EndBlock {
+			return h.current, nil
+		}
+
+		if err := h.commit(false); err != nil {
+			return nil, err
+		}
+
+		if err := h.writeManifest(false); err != nil {
+			return nil, err
+		}
+	}
+
+	file, err := os.Create(filepath.Join(h.directory, fmt.Sprintf("%010d%s%s", blockNum, h.format.Extension(), columnarUncommittedSuffix)))
+	if err != nil {
+		return nil, fmt.Errorf("create file: %w", err)
+	}
+
+	names := make([]string, len(h.columns))
+	for i, column := range h.columns {
+		names[i] = column.Name
+	}
+
+	writer, err := h.format.NewWriter(file, names)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("new %s writer: %w", h.format.Name(), err)
+	}
+
+	h.current = &columnarBundle{
+		ColumnarManifestFile: ColumnarManifestFile{StartBlock: blockNum, EndBlock: blockNum - blockNum%h.bundleSize + h.bundleSize},
+		file:                 file,
+		writer:               writer,
+	}
+
+	return h.current, nil
+}
+
+// commit commits the current bundle, if any, an incomplete bundle ending after its last block.
+// The manifest must be written afterward for the bundle to be listed.
+func (h *ColumnarHandler) commit(incomplete bool) error {
+	bundle := h.current
+	if bundle == nil {
+		return nil
+	}
+
+	h.current = nil
+	if err := bundle.close(); err != nil {
+		return err
+	}
+
+	if incomplete {
+		bundle.EndBlock = bundle.lastBlock + 1
+	}
+
+	bundle.Path = fmt.Sprintf("%010d-%010d%s", bundle.StartBlock, bundle.EndBlock, h.format.Extension())
+	if err := os.Rename(bundle.file.Name(), filepath.Join(h.directory, bundle.Path)); err != nil {
+		return fmt.Errorf("commit file: %w", err)
+	}
+
+	h.sinker.logger.Debug("committed columnar file", zap.String("file", bundle.Path), zap.Uint64("rows", bundle.Rows))
+	h.files = append(h.files, bundle.ColumnarManifestFile)
+	h.cursor = bundle.lastCursor
+	return nil
+}
+
+func (h *ColumnarHandler) writeManifest(completed bool) error {
+	manifest := &ColumnarManifest{
+		OutputModule: h.sinker.OutputModuleName(),
+		ModuleHash:   h.sinker.OutputModuleHash(),
+		Format:       h.format.Name(),
+		BundleSize:   h.bundleSize,
+		RowsPath:     h.rowsPath,
+		Columns:      h.columns,
+		Cursor:       h.cursor.String(),
+		Completed:    completed,
+		Files:        h.files,
+	}
+
+	content, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode manifest: %w", err)
+	}
+
+	path := filepath.Join(h.directory, columnarManifestFile)
+	file, err := os.OpenFile(path+columnarUncommittedSuffix, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("create manifest: %w", err)
+	}
+
+	if _, err := file.Write(content); err != nil {
+		file.Close()
+		return fmt.Errorf("write manifest: %w", err)
+	}
+
+	// Flushed to disk before the rename, otherwise a crash could leave an empty manifest behind
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return fmt.Errorf("sync manifest: %w", err)
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("close manifest: %w", err)
+	}
+
+	if err := os.Rename(path+columnarUncommittedSuffix, path); err != nil {
+		return fmt.Errorf("commit manifest: %w", err)
+	}
+
+	if completed {
+		h.sinker.logger.Info("columnar files manifest written", zap.String("manifest", path), zap.Int("files", len(h.files)))
+	}
+
+	return nil
+}
+
+func (b *columnarBundle) close() error {
+	if err := b.writer.Close(); err != nil {
+		b.file.Close()
+		return fmt.Errorf("close writer: %w", err)
+	}
+
+	if err := b.file.Sync(); err != nil {
+		b.file.Close()
+		return fmt.Errorf("sync file: %w", err)
+	}
+
+	if err := b.file.Close(); err != nil {
+		return fmt.Errorf("close file: %w", err)
+	}
+
+	return nil
+}
+
+// plan resolves the rows path and columns field paths against `descriptor`, once per type.
+func (h *ColumnarHandler) plan(descriptor protoreflect.MessageDescriptor) (*columnarPlan, error) {
+	if plan, found := h.plans[descriptor.FullName()]; found {
+		return plan, nil
+	}
+
+	plan := &columnarPlan{columns: make([][]protoreflect.FieldDescriptor, len(h.columns))}
+
+	rowDescriptor := descriptor
+	if h.rowsPath != "" {
+		fields, err := resolveFieldPath(descriptor, h.rowsPath)
+		if err != nil {
+			return nil, fmt.Errorf("rows path: %w", err)
+		}
+
+		for _, field := range fields {
+			if field.Message() == nil || field.IsMap() {
+				return nil, fmt.Errorf("rows path %q: field %q is not a message", h.rowsPath, field.Name())
+			}
+		}
+
+		plan.rows = fields
+		rowDescriptor = fields[len(fields)-1].Message()
+	}
+
+	for i, column := range h.columns {
+		if strings.HasPrefix(column.FieldPath, "@") {
+			switch column.FieldPath {
+			case ColumnarBlockNumber, ColumnarBlockID, ColumnarBlockTimestamp:
+				continue
+			default:
+				return nil, fmt.Errorf("column %q: unknown block field %q", column.Name, column.FieldPath)
+			}
+		}
+
+		fields, err := resolveFieldPath(rowDescriptor, column.FieldPath)
+		if err != nil {
+			return nil, fmt.Errorf("column %q: %w", column.Name, err)
+		}
+
+		for _, field := range fields[:len(fields)-1] {
+			if field.IsList() || field.IsMap() {
+				return nil, fmt.Errorf("column %q: field %q is repeated, only the last field of a column path can be", column.Name, field.Name())
+			}
+		}
+
+		plan.columns[i] = fields
+	}
+
+	h.plans[descriptor.FullName()] = plan
+	return plan, nil
+}
+
+// resolveFieldPath resolves the `.` separated field names, or JSON names, of `fieldPath`.
+func resolveFieldPath(descriptor protoreflect.MessageDescriptor, fieldPath string) ([]protoreflect.FieldDescriptor, error) {
+	var fields []protoreflect.FieldDescriptor
+	for _, name := range strings.Split(fieldPath, ".") {
+		if descriptor == nil {
+			return nil, fmt.Errorf("field path %q: %q is not a message", fieldPath, fields[len(fields)-1].Name())
+		}
+
+		field := descriptor.Fields().ByName(protoreflect.Name(name))
+		if field == nil {
+			field = descriptor.Fields().ByJSONName(name)
+		}
+
+		if field == nil {
+			return nil, fmt.Errorf("field path %q: no field %q in %q", fieldPath, name, descriptor.FullName())
+		}
+
+		fields = append(fields, field)
+		descriptor = field.Message()
+	}
+
+	return fields, nil
+}
+
+// flattenColumnarRows returns the messages found following `fields` from `message`, each
+// repeated field being flattened.
+func flattenColumnarRows(message protoreflect.Message, fields []protoreflect.FieldDescriptor) []protoreflect.Message {
+	if len(fields) == 0 {
+		return []protoreflect.Message{message}
+	}
+
+	field := fields[0]
+	if field.IsList() {
+		var rows []protoreflect.Message
+		list := message.Get(field).List()
+		for i := 0; i < list.Len(); i++ {
+			rows = append(rows, flattenColumnarRows(list.Get(i).Message(), fields[1:])...)
+		}
+
+		return rows
+	}
+
+	if !message.Has(field) {
+		return nil
+	}
+
+	return flattenColumnarRows(message.Get(field).Message(), fields[1:])
+}
+
+func columnarValue(clock *pbsubstreams.Clock, row protoreflect.Message, fieldPath string, fields []protoreflect.FieldDescriptor) any {
+	switch fieldPath {
+	case ColumnarBlockNumber:
+		return clock.GetNumber()
+	case ColumnarBlockID:
+		return clock.GetId()
+	case ColumnarBlockTimestamp:
+		return clock.GetTimestamp().AsTime()
+	}
+
+	message := row
+	for _, field := range fields[:len(fields)-1] {
+		if !message.Has(field) {
+			return nil
+		}
+
+		message = message.Get(field).Message()
+	}
+
+	field := fields[len(fields)-1]
+	value := message.Get(field)
+
+	switch {
+	case field.IsList() || field.IsMap():
+		if !message.Has(field) {
+			if field.IsMap() {
+				return "{}"
+			}
+
+			return "[]"
+		}
+
+		// Marshalling the message with only this field set renders the field as protojson does
+		wrapper := message.Type().New()
+		wrapper.Set(field, value)
+
+		content, err := protojson.Marshal(wrapper.Interface())
+		if err != nil {
+			return nil
+		}
+
+		var fieldsJSON map[string]json.RawMessage
+		if err := json.Unmarshal(content, &fieldsJSON); err != nil {
+			return nil
+		}
+
+		return compactJSON(fieldsJSON[field.JSONName()])
+
+	case field.Message() != nil:
+		if !message.Has(field) {
+			return nil
+		}
+
+		content, err := protojson.Marshal(value.Message().Interface())
+		if err != nil {
+			return nil
+		}
+
+		return compactJSON(content)
+
+	case field.Enum() != nil:
+		if enumValue := field.Enum().Values().ByNumber(value.Enum()); enumValue != nil {
+			return string(enumValue.Name())
+		}
+
+		return int64(value.Enum())
+	}
+
+	switch field.Kind() {
+	case protoreflect.BoolKind:
+		return value.Bool()
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind, protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return value.Int()
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return value.Uint()
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return value.Float()
+	case protoreflect.BytesKind:
+		return value.Bytes()
+	default:
+		return value.String()
+	}
+}
+
+// compactJSON returns `content` compacted as a string, protojson output being purposely
+// unstable in its whitespaces.
+func compactJSON(content []byte) any {
+	if content == nil {
+		return nil
+	}
+
+	var out bytes.Buffer
+	if err := json.Compact(&out, content); err != nil {
+		return string(content)
+	}
+
+	return out.String()
+}
+
+type csvColumnarFormat struct{}
+
+func (csvColumnarFormat) Name() string      { return "csv" }
+func (csvColumnarFormat) Extension() string { return ".csv" }
+
+func (csvColumnarFormat) NewWriter(out io.Writer, columns []string) (ColumnarWriter, error) {
+	writer := csv.NewWriter(out)
+	if err := writer.Write(columns); err != nil {
+		return nil, fmt.Errorf("write header: %w", err)
+	}
+
+	return &csvColumnarWriter{writer: writer}, nil
+}
+
+type csvColumnarWriter struct {
+	writer *csv.Writer
+	record []string
+}
+
+func (w *csvColumnarWriter) Write(row []any) error {
+	w.record = w.record[:0]
+	for _, value := range row {
+		w.record = append(w.record, formatCSVValue(value))
+	}
+
+	return w.writer.Write(w.record)
+}
+
+func (w *csvColumnarWriter) Close() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+func formatCSVValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case bool:
+		return strconv.FormatBool(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case string:
+		return v
+	case []byte:
+		return hex.EncodeToString(v)
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(v)
+	}
+}
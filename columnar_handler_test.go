@@ -0,0 +1,272 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/streamingfast/bstream"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func TestColumnarHandler(t *testing.T) {
+	ctx := context.Background()
+	directory := t.TempDir()
+
+	handler, err := NewColumnarHandler(newTestSinker(t, WithFinalBlocksOnly()), directory, []ColumnarColumn{
+		{Name: "block", FieldPath: ColumnarBlockNumber},
+		{Name: "name", FieldPath: "name"},
+		{Name: "initial_block", FieldPath: "initialBlock"},
+		{Name: "output_type", FieldPath: "kind_map.output_type"},
+		{Name: "update_policy", FieldPath: "kind_store.update_policy"},
+		{Name: "inputs", FieldPath: "inputs"},
+	}, WithColumnarBundleSize(10), WithColumnarRowsPath("modules"))
+	require.NoError(t, err)
+
+	modules := &pbsubstreams.Modules{Modules: []*pbsubstreams.Module{
+		{
+			Name:         "map_a",
+			InitialBlock: 10,
+			Kind:         &pbsubstreams.Module_KindMap_{KindMap: &pbsubstreams.Module_KindMap{OutputType: "proto:acme.v1.A"}},
+			Inputs: []*pbsubstreams.Module_Input{
+				{Input: &pbsubstreams.Module_Input_Source_{Source: &pbsubstreams.Module_Input_Source{Type: "sf.acme.v1.Block"}}},
+				{Input: &pbsubstreams.Module_Input_Params_{Params: &pbsubstreams.Module_Input_Params{Value: "a,b"}}},
+			},
+		},
+		{
+			Name: "store_b",
+			Kind: &pbsubstreams.Module_KindStore_{KindStore: &pbsubstreams.Module_KindStore{UpdatePolicy: pbsubstreams.Module_KindStore_UPDATE_POLICY_ADD}},
+		},
+	}}
+
+	for _, num := range []uint64{8, 9, 12} {
+		testColumnarHandleBlock(t, handler, num, modules)
+	}
+	testColumnarHandleBlock(t, handler, 13, &pbsubstreams.Modules{})
+
+	assert.Equal(t, []string{"0000000008-0000000010.csv", "0000000012.csv.partial", "manifest.json"}, testDirectoryFiles(t, directory))
+	require.NoError(t, handler.HandleBlockRangeCompletion(ctx, testCursor(15, bstream.StepNewIrreversible)))
+	assert.Equal(t, []string{"0000000008-0000000010.csv", "0000000012-0000000014.csv", "manifest.json"}, testDirectoryFiles(t, directory))
+
+	content, err := os.ReadFile(filepath.Join(directory, "0000000008-0000000010.csv"))
+	require.NoError(t, err)
+	assert.Equal(t, `block,name,initial_block,output_type,update_policy,inputs
+8,map_a,10,proto:acme.v1.A,,"[{""source"":{""type"":""sf.acme.v1.Block""}},{""params"":{""value"":""a,b""}}]"
+8,store_b,0,,UPDATE_POLICY_ADD,[]
+9,map_a,10,proto:acme.v1.A,,"[{""source"":{""type"":""sf.acme.v1.Block""}},{""params"":{""value"":""a,b""}}]"
+9,store_b,0,,UPDATE_POLICY_ADD,[]
+`, string(content))
+
+	content, err = os.ReadFile(filepath.Join(directory, "manifest.json"))
+	require.NoError(t, err)
+
+	var manifest ColumnarManifest
+	require.NoError(t, json.Unmarshal(content, &manifest))
+	assert.Equal(t, "kv_out", manifest.OutputModule)
+	assert.Equal(t, testKVOutModuleHash, manifest.ModuleHash)
+	assert.Equal(t, "csv", manifest.Format)
+	assert.True(t, manifest.Completed)
+	assert.Equal(t, testCursor(15, bstream.StepNewIrreversible).String(), manifest.Cursor)
+	assert.Equal(t, []ColumnarManifestFile{
+		{Path: "0000000008-0000000010.csv", StartBlock: 8, EndBlock: 10, Blocks: 2, Rows: 4},
+		{Path: "0000000012-0000000014.csv", StartBlock: 12, EndBlock: 14, Blocks: 2, Rows: 2},
+	}, manifest.Files)
+}
+
+func TestColumnarHandler_Resume(t *testing.T) {
+	ctx := context.Background()
+	directory := t.TempDir()
+	columns := []ColumnarColumn{{Name: "block", FieldPath: ColumnarBlockNumber}, {Name: "name", FieldPath: "name"}}
+	modules := &pbsubstreams.Modules{Modules: []*pbsubstreams.Module{{Name: "map_a"}}}
+
+	handler, err := NewColumnarHandler(newTestSinker(t, WithFinalBlocksOnly()), directory, columns, WithColumnarBundleSize(10), WithColumnarRowsPath("modules"))
+	require.NoError(t, err)
+
+	for _, num := range []uint64{3, 11, 12} {
+		testColumnarHandleBlock(t, handler, num, modules)
+	}
+
+	// The process dies in the middle of the second bundle, only the first one is committed
+	require.NoError(t, handler.Close())
+	assert.Equal(t, []string{"0000000003-0000000010.csv", "0000000011.csv.partial", "manifest.json"}, testDirectoryFiles(t, directory))
+
+	handler, err = NewColumnarHandler(newTestSinker(t, WithFinalBlocksOnly()), directory, columns, WithColumnarBundleSize(10), WithColumnarRowsPath("modules"))
+	require.NoError(t, err)
+
+	cursor, err := handler.LoadCursor(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), cursor.Block().Num())
+	assert.Equal(t, []string{"0000000003-0000000010.csv", "manifest.json"}, testDirectoryFiles(t, directory))
+
+	for _, num := range []uint64{11, 12} {
+		testColumnarHandleBlock(t, handler, num, modules)
+	}
+	require.NoError(t, handler.HandleBlockRangeCompletion(ctx, testCursor(12, bstream.StepNewIrreversible)))
+
+	content, err := os.ReadFile(filepath.Join(directory, "manifest.json"))
+	require.NoError(t, err)
+
+	var manifest ColumnarManifest
+	require.NoError(t, json.Unmarshal(content, &manifest))
+	assert.Equal(t, []ColumnarManifestFile{
+		{Path: "0000000003-0000000010.csv", StartBlock: 3, EndBlock: 10, Blocks: 1, Rows: 1},
+		{Path: "0000000011-0000000013.csv", StartBlock: 11, EndBlock: 13, Blocks: 2, Rows: 2},
+	}, manifest.Files)
+
+	content, err = os.ReadFile(filepath.Join(directory, "0000000011-0000000013.csv"))
+	require.NoError(t, err)
+	assert.Equal(t, "block,name\n11,map_a\n12,map_a\n", string(content))
+}
+
+func TestColumnarHandler_LoadCursor(t *testing.T) {
+	ctx := context.Background()
+	directory := t.TempDir()
+	columns := []ColumnarColumn{{Name: "name", FieldPath: "name"}}
+
+	handler, err := NewColumnarHandler(newTestSinker(t, WithFinalBlocksOnly()), directory, columns, WithColumnarRowsPath("modules"))
+	require.NoError(t, err)
+
+	cursor, err := handler.LoadCursor(ctx)
+	require.NoError(t, err)
+	assert.True(t, cursor.IsBlank())
+
+	testColumnarHandleBlock(t, handler, 1, &pbsubstreams.Modules{})
+	require.NoError(t, handler.HandleBlockRangeCompletion(ctx, testCursor(1, bstream.StepNewIrreversible)))
+
+	// Committed by a previous run right before it died, without being listed in the manifest
+	for _, name := range []string{"0000000002-0000000003.csv", "0000000003.csv.partial", "notes.txt"} {
+		require.NoError(t, os.WriteFile(filepath.Join(directory, name), nil, 0o644))
+	}
+
+	handler, err = NewColumnarHandler(newTestSinker(t, WithFinalBlocksOnly()), directory, columns, WithColumnarRowsPath("modules"))
+	require.NoError(t, err)
+
+	cursor, err = handler.LoadCursor(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), cursor.Block().Num())
+	assert.Equal(t, []string{"0000000001-0000000002.csv", "manifest.json", "notes.txt"}, testDirectoryFiles(t, directory))
+
+	handler, err = NewColumnarHandler(newTestSinker(t, WithFinalBlocksOnly()), directory, []ColumnarColumn{{Name: "module", FieldPath: "name"}}, WithColumnarRowsPath("modules"))
+	require.NoError(t, err)
+
+	_, err = handler.LoadCursor(ctx)
+	assert.EqualError(t, err, "output directory was produced with a different format, rows path or columns")
+
+	content, err := os.ReadFile(filepath.Join(directory, "manifest.json"))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(directory, "manifest.json"), []byte(strings.Replace(string(content), testKVOutModuleHash, "abcd", 1)), 0o644))
+
+	handler, err = NewColumnarHandler(newTestSinker(t, WithFinalBlocksOnly()), directory, columns, WithColumnarRowsPath("modules"))
+	require.NoError(t, err)
+
+	_, err = handler.LoadCursor(ctx)
+	assert.ErrorIs(t, err, ErrModuleHashChanged)
+}
+
+func TestColumnarHandler_NestedRows(t *testing.T) {
+	directory := t.TempDir()
+
+	handler, err := NewColumnarHandler(newTestSinker(t, WithFinalBlocksOnly()), directory, []ColumnarColumn{
+		{Name: "block_id", FieldPath: ColumnarBlockID},
+		{Name: "source", FieldPath: "source.type"},
+		{Name: "store_mode", FieldPath: "store.mode"},
+		{Name: "params", FieldPath: "params"},
+	}, WithColumnarRowsPath("modules.inputs"))
+	require.NoError(t, err)
+
+	testColumnarHandleBlock(t, handler, 1, &pbsubstreams.Modules{Modules: []*pbsubstreams.Module{
+		{Inputs: []*pbsubstreams.Module_Input{{Input: &pbsubstreams.Module_Input_Source_{Source: &pbsubstreams.Module_Input_Source{Type: "sf.acme.v1.Block"}}}}},
+		{Inputs: []*pbsubstreams.Module_Input{
+			{Input: &pbsubstreams.Module_Input_Store_{Store: &pbsubstreams.Module_Input_Store{Mode: pbsubstreams.Module_Input_Store_DELTAS}}},
+			{Input: &pbsubstreams.Module_Input_Params_{Params: &pbsubstreams.Module_Input_Params{Value: "x"}}},
+		}},
+	}})
+	require.NoError(t, handler.HandleBlockRangeCompletion(context.Background(), testCursor(1, bstream.StepNewIrreversible)))
+
+	content, err := os.ReadFile(filepath.Join(directory, "0000000001-0000000002.csv"))
+	require.NoError(t, err)
+	assert.Equal(t, `block_id,source,store_mode,params
+1a,sf.acme.v1.Block,,
+1a,,DELTAS,
+1a,,,"{""value"":""x""}"
+`, string(content))
+}
+
+func TestColumnarHandler_Errors(t *testing.T) {
+	_, err := NewColumnarHandler(newTestSinker(t), t.TempDir(), []ColumnarColumn{{Name: "name", FieldPath: "name"}})
+	assert.EqualError(t, err, "columnar handler requires the sinker to stream final blocks only, see WithFinalBlocksOnly")
+
+	tests := []struct {
+		name          string
+		rowsPath      string
+		columns       []ColumnarColumn
+		expectedError string
+	}{
+		{"unknown field", "modules", []ColumnarColumn{{Name: "a", FieldPath: "unknown"}}, `column "a": field path "unknown": no field "unknown" in "sf.substreams.v1.Module"`},
+		{"scalar not a message", "modules", []ColumnarColumn{{Name: "a", FieldPath: "name.length"}}, `column "a": field path "name.length": "name" is not a message`},
+		{"repeated inside path", "", []ColumnarColumn{{Name: "a", FieldPath: "modules.name"}}, `column "a": field "modules" is repeated, only the last field of a column path can be`},
+		{"rows path not a message", "modules.name", []ColumnarColumn{{Name: "a", FieldPath: "name"}}, `rows path "modules.name": field "name" is not a message`},
+		{"unknown block field", "", []ColumnarColumn{{Name: "a", FieldPath: "@block_hash"}}, `column "a": unknown block field "@block_hash"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, err := NewColumnarHandler(newTestSinker(t, WithFinalBlocksOnly()), t.TempDir(), tt.columns, WithColumnarRowsPath(tt.rowsPath))
+			require.NoError(t, err)
+
+			data := testCursorDataResponse(1).GetBlockScopedData()
+			data.Output.MapOutput = testModulesOutput(t, &pbsubstreams.Modules{})
+
+			assert.EqualError(t, handler.HandleBlockScopedData(context.Background(), data, nil, nil), tt.expectedError)
+		})
+	}
+}
+
+func testColumnarHandleBlock(t *testing.T, handler *ColumnarHandler, num uint64, modules *pbsubstreams.Modules) {
+	t.Helper()
+
+	data := testCursorDataResponse(num).GetBlockScopedData()
+	data.Output.MapOutput = testModulesOutput(t, modules)
+
+	cursor, err := NewCursor(data.Cursor)
+	require.NoError(t, err)
+
+	require.NoError(t, handler.HandleBlockScopedData(context.Background(), data, nil, cursor))
+}
+
+func testModulesOutput(t *testing.T, modules *pbsubstreams.Modules) *anypb.Any {
+	t.Helper()
+
+	content, err := proto.Marshal(modules)
+	require.NoError(t, err)
+
+	return &anypb.Any{TypeUrl: "type.googleapis.com/sf.substreams.v1.Modules", Value: content}
+}
+
+func TestFormatCSVValue(t *testing.T) {
+	tests := []struct {
+		value    any
+		expected string
+	}{
+		{nil, ""},
+		{true, "true"},
+		{int64(-12), "-12"},
+		{uint64(12), "12"},
+		{1.5, "1.5"},
+		{"text", "text"},
+		{[]byte{0xde, 0xad}, "dead"},
+		{time.Date(2023, 11, 14, 22, 13, 20, 0, time.UTC), "2023-11-14T22:13:20Z"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, formatCSVValue(tt.value), "%#v", tt.value)
+	}
+}
@@ -0,0 +1,111 @@
+package sink
+
+import (
+	"cmp"
+	"fmt"
+
+	"github.com/streamingfast/bstream"
+)
+
+// HeadBlock returns the chain's head block when the cursor was produced, it's the same as
+// [Cursor.Block] except during a reorganization or when the cursor marks blocks as final.
+func (c *Cursor) HeadBlock() bstream.BlockRef {
+	if c.IsBlank() {
+		return unsetBlockRef{}
+	}
+
+	return c.Cursor.HeadBlock
+}
+
+// LIB returns the last irreversible block when the cursor was produced.
+func (c *Cursor) LIB() bstream.BlockRef {
+	if c.IsBlank() {
+		return unsetBlockRef{}
+	}
+
+	return c.Cursor.LIB
+}
+
+// Step returns the step of [Cursor.Block], 0 for a blank cursor.
+func (c *Cursor) Step() bstream.StepType {
+	if c.IsBlank() {
+		return 0
+	}
+
+	return c.Cursor.Step
+}
+
+// Compare returns -1 if `c` is before `other` in the stream, 1 if it's after and 0 if both are
+// at the same position. Cursors are ordered by block number then by step, a new block coming
+// before its undo which comes before it being final. A blank cursor is before any other cursor.
+func (c *Cursor) Compare(other *Cursor) int {
+	switch {
+	case c.IsBlank() && other.IsBlank():
+		return 0
+	case c.IsBlank():
+		return -1
+	case other.IsBlank():
+		return 1
+	}
+
+	if c.Block().Num() != other.Block().Num() {
+		return cmp.Compare(c.Block().Num(), other.Block().Num())
+	}
+
+	return cmp.Compare(stepOrder(c.Step()), stepOrder(other.Step()))
+}
+
+// IsBefore returns true if `c` is before `other` in the stream, see [Cursor.Compare].
+func (c *Cursor) IsBefore(other *Cursor) bool {
+	return c.Compare(other) < 0
+}
+
+// IsAfter returns true if `c` is after `other` in the stream, see [Cursor.Compare].
+func (c *Cursor) IsAfter(other *Cursor) bool {
+	return c.Compare(other) > 0
+}
+
+// Describe returns a human-readable description of the cursor, for example
+// `#10 (10a) [new], head #10 (10a), LIB #8 (8a)`, `<Blank>` for a blank cursor.
+func (c *Cursor) Describe() string {
+	if c.IsBlank() {
+		return "<Blank>"
+	}
+
+	return fmt.Sprintf("%s [%s], head %s, LIB %s", c.Block(), c.Step(), c.HeadBlock(), c.LIB())
+}
+
+// MarshalText encodes the cursor as its opaque form, see [Cursor.String], which also makes it
+// marshal as a JSON string.
+func (c *Cursor) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// UnmarshalText decodes the opaque form of a cursor, an empty text being a blank cursor.
+func (c *Cursor) UnmarshalText(text []byte) error {
+	decoded, err := NewCursor(string(text))
+	if err != nil {
+		return err
+	}
+
+	c.Cursor = nil
+	if !decoded.IsBlank() {
+		c.Cursor = decoded.Cursor
+	}
+
+	return nil
+}
+
+// stepOrder orders steps as they are seen in the stream for a given block.
+func stepOrder(step bstream.StepType) uint64 {
+	switch {
+	case step.Matches(bstream.StepStalled):
+		return 3
+	case step.Matches(bstream.StepIrreversible):
+		return 2
+	case step.Matches(bstream.StepUndo):
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,187 @@
+package sink
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	"github.com/streamingfast/bstream"
+	"github.com/streamingfast/cli/sflags"
+)
+
+const (
+	FlagCursorFile    = "cursor-file"
+	FlagCursorBlock   = "block"
+	FlagCursorBlockID = "block-id"
+)
+
+// CursorStoreFactory returns the [CursorStore] the `cursor` subcommands operate on, it
+// receives the subcommand being executed to read its flags.
+type CursorStoreFactory func(cmd *cobra.Command) (CursorStore, error)
+
+// NewCursorCmd returns a `cursor` command, to mount into a sink's CLI, with subcommands to
+// inspect and rewrite the cursor stored in the [CursorStore] returned by `storeFactory`:
+//
+//	cursor show                                   Prints the stored cursor and output module hash
+//	cursor decode <opaque>                        Prints the decoded opaque cursor, no store involved
+//	cursor set <opaque>                           Stores the opaque cursor
+//	cursor set --block <num> [--block-id <id>]    Stores a cursor restarting after the final block <num>
+//	cursor reset                                  Clears the stored cursor
+//
+// The stored output module hash is kept by `set` and cleared by `reset`. When `storeFactory`
+// is nil, the command defines a persistent `--cursor-file` flag and operates on a
+// [FileCursorStore], see [WithCursorStore].
+func NewCursorCmd(storeFactory CursorStoreFactory) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cursor",
+		Short: "Inspect, rewrite or reset the cursor of the sink",
+	}
+
+	if storeFactory == nil {
+		cmd.PersistentFlags().String(FlagCursorFile, "cursor.json", "Path of the file the cursor is stored in")
+		storeFactory = func(cmd *cobra.Command) (CursorStore, error) {
+			return NewFileCursorStore(sflags.MustGetString(cmd, FlagCursorFile)), nil
+		}
+	}
+
+	cmd.AddCommand(
+		&cobra.Command{
+			Use:   "show",
+			Short: "Prints the stored cursor and output module hash",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				store, err := storeFactory(cmd)
+				if err != nil {
+					return fmt.Errorf("cursor store: %w", err)
+				}
+
+				cursor, moduleHash, err := store.Load(cmd.Context())
+				if err != nil {
+					return fmt.Errorf("load cursor: %w", err)
+				}
+
+				printCursor(cmd.OutOrStdout(), cursor)
+				fmt.Fprintf(cmd.OutOrStdout(), "Module Hash: %s\n", valueOrNone(moduleHash))
+				return nil
+			},
+		},
+		&cobra.Command{
+			Use:   "decode <opaque>",
+			Short: "Prints the decoded opaque cursor",
+			Args:  cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				cursor, err := NewCursor(args[0])
+				if err != nil {
+					return err
+				}
+
+				printCursor(cmd.OutOrStdout(), cursor)
+				return nil
+			},
+		},
+		newCursorSetCmd(storeFactory),
+		&cobra.Command{
+			Use:   "reset",
+			Short: "Clears the stored cursor, the sink then restarts from the start of its block range",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				store, err := storeFactory(cmd)
+				if err != nil {
+					return fmt.Errorf("cursor store: %w", err)
+				}
+
+				if err := store.Save(cmd.Context(), NewBlankCursor(), ""); err != nil {
+					return fmt.Errorf("save cursor: %w", err)
+				}
+
+				fmt.Fprintln(cmd.OutOrStdout(), "Cursor reset")
+				return nil
+			},
+		},
+	)
+
+	return cmd
+}
+
+func newCursorSetCmd(storeFactory CursorStoreFactory) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "set [<opaque>]",
+		Short: "Stores the opaque cursor or a cursor restarting after the block given with --block",
+		Long: `Stores the opaque cursor or a cursor restarting after the block given with --block.
+
+A cursor built from --block is marked final, the block must be final on the chain and its ID
+must be given with --block-id since the Substreams endpoint validates it when resuming.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			blockNum, blockProvided := sflags.MustGetUint64Provided(cmd, FlagCursorBlock)
+			if blockProvided == (len(args) == 1) {
+				return fmt.Errorf("exactly one of <opaque> argument or --%s flag must be given", FlagCursorBlock)
+			}
+
+			var cursor *Cursor
+			if blockProvided {
+				blockID := sflags.MustGetString(cmd, FlagCursorBlockID)
+				if blockID == "" {
+					return fmt.Errorf("--%s flag is required with --%s", FlagCursorBlockID, FlagCursorBlock)
+				}
+
+				cursor = newBlockCursor(blockNum, blockID)
+			} else {
+				var err error
+				if cursor, err = NewCursor(args[0]); err != nil {
+					return err
+				}
+			}
+
+			store, err := storeFactory(cmd)
+			if err != nil {
+				return fmt.Errorf("cursor store: %w", err)
+			}
+
+			_, moduleHash, err := store.Load(cmd.Context())
+			if err != nil {
+				return fmt.Errorf("load cursor: %w", err)
+			}
+
+			if err := store.Save(cmd.Context(), cursor, moduleHash); err != nil {
+				return fmt.Errorf("save cursor: %w", err)
+			}
+
+			printCursor(cmd.OutOrStdout(), cursor)
+			return nil
+		},
+	}
+
+	cmd.Flags().Uint64(FlagCursorBlock, 0, "Block number of the cursor, the sink restarts after this block")
+	cmd.Flags().String(FlagCursorBlockID, "", "Block ID of the cursor, required with --block")
+
+	return cmd
+}
+
+// newBlockCursor returns a cursor restarting after the final block `num`.
+func newBlockCursor(num uint64, id string) *Cursor {
+	block := bstream.NewBlockRef(id, num)
+
+	return &Cursor{&bstream.Cursor{Step: bstream.StepNewIrreversible, Block: block, HeadBlock: block, LIB: block}}
+}
+
+func printCursor(out io.Writer, cursor *Cursor) {
+	if cursor.IsBlank() {
+		fmt.Fprintln(out, "Cursor:      <Blank>")
+		return
+	}
+
+	fmt.Fprintf(out, "Cursor:      %s\n", cursor)
+	fmt.Fprintf(out, "Block:       %s\n", cursor.Block())
+	fmt.Fprintf(out, "Step:        %s\n", cursor.Step())
+	fmt.Fprintf(out, "Head Block:  %s\n", cursor.HeadBlock())
+	fmt.Fprintf(out, "LIB:         %s\n", cursor.LIB())
+}
+
+func valueOrNone(value string) string {
+	if value == "" {
+		return "<None>"
+	}
+
+	return value
+}
@@ -0,0 +1,73 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/streamingfast/bstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCursorCmd(t *testing.T) {
+	cursorFile := filepath.Join(t.TempDir(), "cursor.json")
+	store := NewFileCursorStore(cursorFile)
+	opaque := testCursor(10, bstream.StepNew).String()
+
+	run := func(args ...string) (string, error) {
+		var out bytes.Buffer
+
+		cmd := NewCursorCmd(nil)
+		cmd.SetArgs(append(args, "--cursor-file", cursorFile))
+		cmd.SetOut(&out)
+		cmd.SetErr(&out)
+
+		err := cmd.ExecuteContext(context.Background())
+		return out.String(), err
+	}
+
+	out, err := run("show")
+	require.NoError(t, err)
+	assert.Equal(t, "Cursor:      <Blank>\nModule Hash: <None>\n", out)
+
+	out, err = run("decode", opaque)
+	require.NoError(t, err)
+	assert.Equal(t, "Cursor:      "+opaque+"\nBlock:       #10 (10a)\nStep:        new\nHead Block:  #10 (10a)\nLIB:         #0 (0a)\n", out)
+
+	_, err = run("decode", "invalid")
+	var cursorErr *CursorError
+	assert.ErrorAs(t, err, &cursorErr)
+
+	require.NoError(t, store.Save(context.Background(), NewBlankCursor(), "abcd"))
+	_, err = run("set", opaque)
+	require.NoError(t, err)
+
+	cursor, hash, err := store.Load(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, opaque, cursor.String())
+	assert.Equal(t, "abcd", hash, "module hash kept")
+
+	_, err = run("set", "--block", "42")
+	assert.EqualError(t, err, "--block-id flag is required with --block")
+
+	_, err = run("set", "--block", "42", "--block-id", "42a")
+	require.NoError(t, err)
+
+	out, err = run("show")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Block:       #42 (42a)\nStep:        new,irreversible\n")
+	assert.Contains(t, out, "Module Hash: abcd\n")
+
+	_, err = run("set", opaque, "--block", "42")
+	assert.EqualError(t, err, "exactly one of <opaque> argument or --block flag must be given")
+
+	_, err = run("reset")
+	require.NoError(t, err)
+
+	cursor, hash, err = store.Load(context.Background())
+	require.NoError(t, err)
+	assert.True(t, cursor.IsBlank())
+	assert.Equal(t, "", hash)
+}
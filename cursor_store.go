@@ -0,0 +1,93 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// CursorStore persists the cursor of the last block handled along with the hash of the output
+// module that produced it, see [WithCursorStore].
+type CursorStore interface {
+	// Load returns the stored cursor and output module hash, a blank cursor and an empty hash
+	// when nothing is stored yet.
+	Load(ctx context.Context) (cursor *Cursor, moduleHash string, err error)
+
+	// Save stores `cursor` and the hash of the output module that produced it.
+	Save(ctx context.Context, cursor *Cursor, moduleHash string) error
+}
+
+// FileCursorStore is a [CursorStore] keeping the cursor in a JSON file, the file is replaced
+// atomically on each save.
+type FileCursorStore struct {
+	path string
+}
+
+func NewFileCursorStore(path string) *FileCursorStore {
+	return &FileCursorStore{path: path}
+}
+
+type fileCursorStoreContent struct {
+	Cursor     string `json:"cursor"`
+	ModuleHash string `json:"module_hash"`
+}
+
+func (s *FileCursorStore) Load(_ context.Context) (*Cursor, string, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return NewBlankCursor(), "", nil
+		}
+
+		return nil, "", fmt.Errorf("read cursor file: %w", err)
+	}
+
+	var content fileCursorStoreContent
+	if err := json.Unmarshal(data, &content); err != nil {
+		return nil, "", fmt.Errorf("decode cursor file %q: %w", s.path, err)
+	}
+
+	cursor, err := NewCursor(content.Cursor)
+	if err != nil {
+		return nil, "", fmt.Errorf("cursor file %q: %w", s.path, err)
+	}
+
+	return cursor, content.ModuleHash, nil
+}
+
+func (s *FileCursorStore) Save(_ context.Context, cursor *Cursor, moduleHash string) error {
+	data, err := json.Marshal(fileCursorStoreContent{Cursor: cursor.String(), ModuleHash: moduleHash})
+	if err != nil {
+		return fmt.Errorf("encode cursor: %w", err)
+	}
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create temporary cursor file: %w", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("write temporary cursor file: %w", err)
+	}
+
+	// Flushed to disk before the rename, otherwise a crash could leave an empty cursor file behind
+	if err := tmpFile.Sync(); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("sync temporary cursor file: %w", err)
+	}
+
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("close temporary cursor file: %w", err)
+	}
+
+	if err := os.Rename(tmpFile.Name(), s.path); err != nil {
+		return fmt.Errorf("replace cursor file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,40 @@
+package sink
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileCursorStore(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "cursor.json")
+	store := NewFileCursorStore(path)
+
+	cursor, hash, err := store.Load(ctx)
+	require.NoError(t, err)
+	assert.True(t, cursor.IsBlank(), "missing file is a blank cursor")
+	assert.Equal(t, "", hash)
+
+	saved := MustNewCursor(testCursorDataResponse(10).GetBlockScopedData().Cursor)
+	require.NoError(t, store.Save(ctx, saved, "abcd"))
+
+	cursor, hash, err = store.Load(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, saved.String(), cursor.String())
+	assert.Equal(t, "abcd", hash)
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "temporary file removed")
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"cursor":"invalid"}`), 0644))
+	_, _, err = store.Load(ctx)
+
+	var cursorErr *CursorError
+	assert.ErrorAs(t, err, &cursorErr)
+}
@@ -0,0 +1,95 @@
+package sink
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/streamingfast/bstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursor_Compare(t *testing.T) {
+	tests := []struct {
+		name          string
+		left          *Cursor
+		right         *Cursor
+		expected      int
+		expectedEqual bool
+	}{
+		{"both blank", NewBlankCursor(), NewBlankCursor(), 0, true},
+		{"blank before", NewBlankCursor(), testCursor(1, bstream.StepNew), -1, false},
+		{"blank after", testCursor(1, bstream.StepNew), NewBlankCursor(), 1, false},
+		{"same", testCursor(5, bstream.StepNew), testCursor(5, bstream.StepNew), 0, true},
+		{"lower block", testCursor(4, bstream.StepIrreversible), testCursor(5, bstream.StepNew), -1, false},
+		{"higher block", testCursor(6, bstream.StepNew), testCursor(5, bstream.StepIrreversible), 1, false},
+		{"new before undo", testCursor(5, bstream.StepNew), testCursor(5, bstream.StepUndo), -1, true},
+		{"undo before irreversible", testCursor(5, bstream.StepUndo), testCursor(5, bstream.StepIrreversible), -1, true},
+		{"new irreversible after new", testCursor(5, bstream.StepNewIrreversible), testCursor(5, bstream.StepNew), 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.left.Compare(tt.right))
+			assert.Equal(t, -tt.expected, tt.right.Compare(tt.left))
+			assert.Equal(t, tt.expected < 0, tt.left.IsBefore(tt.right))
+			assert.Equal(t, tt.expected > 0, tt.left.IsAfter(tt.right))
+			assert.Equal(t, tt.expectedEqual, tt.left.IsEqualTo(tt.right))
+		})
+	}
+}
+
+func TestCursor_IsEqualTo(t *testing.T) {
+	assert.True(t, testCursor(5, bstream.StepNew).IsEqualTo(testCursor(5, bstream.StepNew)))
+	assert.False(t, testCursor(5, bstream.StepNew).IsEqualTo(testCursor(6, bstream.StepNew)))
+	assert.False(t, testCursor(5, bstream.StepNew).IsEqualTo(NewBlankCursor()))
+	assert.False(t, NewBlankCursor().IsEqualTo(testCursor(5, bstream.StepNew)))
+}
+
+func TestCursor_Accessors(t *testing.T) {
+	cursor := MustNewCursor((&bstream.Cursor{
+		Step:      bstream.StepUndo,
+		Block:     bstream.NewBlockRef("10b", 10),
+		HeadBlock: bstream.NewBlockRef("11a", 11),
+		LIB:       bstream.NewBlockRef("8a", 8),
+	}).ToOpaque())
+
+	assert.Equal(t, bstream.StepUndo, cursor.Step())
+	assert.Equal(t, uint64(11), cursor.HeadBlock().Num())
+	assert.Equal(t, uint64(8), cursor.LIB().Num())
+	assert.Equal(t, "#10 (10b) [undo], head #11 (11a), LIB #8 (8a)", cursor.Describe())
+
+	blank := NewBlankCursor()
+	assert.Equal(t, bstream.StepType(0), blank.Step())
+	assert.Equal(t, uint64(0), blank.HeadBlock().Num())
+	assert.Equal(t, uint64(0), blank.LIB().Num())
+	assert.Equal(t, "<Blank>", blank.Describe())
+}
+
+func TestCursor_JSON(t *testing.T) {
+	type config struct {
+		Cursor *Cursor `json:"cursor"`
+	}
+
+	cursor := testCursor(5, bstream.StepNew)
+	data, err := json.Marshal(config{Cursor: cursor})
+	require.NoError(t, err)
+	assert.JSONEq(t, fmt.Sprintf(`{"cursor":%q}`, cursor.String()), string(data))
+
+	var decoded config
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.True(t, cursor.IsEqualTo(decoded.Cursor))
+
+	require.NoError(t, json.Unmarshal([]byte(`{"cursor":""}`), &decoded))
+	assert.True(t, decoded.Cursor.IsBlank())
+
+	var cursorErr *CursorError
+	assert.ErrorAs(t, json.Unmarshal([]byte(`{"cursor":"invalid"}`), &decoded), &cursorErr)
+}
+
+func testCursor(num uint64, step bstream.StepType) *Cursor {
+	block := bstream.NewBlockRef(fmt.Sprintf("%da", num), num)
+
+	return MustNewCursor((&bstream.Cursor{Step: step, Block: block, HeadBlock: block, LIB: bstream.NewBlockRef("0a", 0)}).ToOpaque())
+}
@@ -0,0 +1,177 @@
+package sink
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// outputDecoder decodes module outputs using the Protobuf descriptors embedded in a package,
+// without requiring the generated Go types.
+type outputDecoder struct {
+	files *protoregistry.Files
+	types *dynamicpb.Types
+}
+
+// newOutputDecoder builds the descriptors registry of `pkg`, imports that are not part of the
+// package, like Protobuf well-known types, are resolved from the global registry.
+func newOutputDecoder(pkg *pbsubstreams.Package) (*outputDecoder, error) {
+	fileSet := &descriptorpb.FileDescriptorSet{File: pkg.GetProtoFiles()}
+
+	known := make(map[string]bool, len(fileSet.File))
+	for _, file := range fileSet.File {
+		known[file.GetName()] = true
+	}
+
+	for _, file := range pkg.GetProtoFiles() {
+		for _, dependency := range file.GetDependency() {
+			fileSet.File = appendGlobalFile(fileSet.File, known, dependency)
+		}
+	}
+
+	files, err := protodesc.NewFiles(fileSet)
+	if err != nil {
+		return nil, fmt.Errorf("build descriptors registry from package: %w", err)
+	}
+
+	return &outputDecoder{files: files, types: dynamicpb.NewTypes(files)}, nil
+}
+
+// appendGlobalFile appends the descriptor of `name`, and its own dependencies, from the global
+// registry if not already known.
+func appendGlobalFile(files []*descriptorpb.FileDescriptorProto, known map[string]bool, name string) []*descriptorpb.FileDescriptorProto {
+	if known[name] {
+		return files
+	}
+
+	descriptor, err := protoregistry.GlobalFiles.FindFileByPath(name)
+	if err != nil {
+		// Left unresolved, building the registry reports it
+		return files
+	}
+
+	known[name] = true
+	for i := 0; i < descriptor.Imports().Len(); i++ {
+		files = appendGlobalFile(files, known, descriptor.Imports().Get(i).Path())
+	}
+
+	return append(files, protodesc.ToFileDescriptorProto(descriptor))
+}
+
+// Decode decodes `output` into a dynamic message of the type identified by its type URL.
+func (d *outputDecoder) Decode(output *anypb.Any) (*dynamicpb.Message, error) {
+	if output == nil {
+		return nil, errors.New("no output to decode")
+	}
+
+	messageName := protoreflect.FullName(output.GetTypeUrl())
+	if i := strings.LastIndex(output.GetTypeUrl(), "/"); i >= 0 {
+		messageName = protoreflect.FullName(output.GetTypeUrl()[i+1:])
+	}
+
+	descriptor, err := d.files.FindDescriptorByName(messageName)
+	if err != nil {
+		return nil, fmt.Errorf("find message %q in package descriptors: %w", messageName, err)
+	}
+
+	messageDescriptor, ok := descriptor.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%q is not a message", messageName)
+	}
+
+	message := dynamicpb.NewMessage(messageDescriptor)
+	if err := proto.Unmarshal(output.GetValue(), message); err != nil {
+		return nil, fmt.Errorf("unmarshal %q: %w", messageName, err)
+	}
+
+	return message, nil
+}
+
+// DecodeJSON decodes `output` into its canonical Protobuf JSON form, compacted as protojson
+// output is purposely unstable in its whitespaces.
+func (d *outputDecoder) DecodeJSON(output *anypb.Any) ([]byte, error) {
+	message, err := d.Decode(output)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := protojson.MarshalOptions{Resolver: d.types}.Marshal(message)
+	if err != nil {
+		return nil, fmt.Errorf("marshal %q to JSON: %w", message.Descriptor().FullName(), err)
+	}
+
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, content); err != nil {
+		return nil, fmt.Errorf("compact JSON: %w", err)
+	}
+
+	return compacted.Bytes(), nil
+}
+
+// ProtoFiles returns the registry of the Protobuf descriptors embedded in the package, imports
+// that are not part of the package, like Protobuf well-known types, being resolved from the
+// global registry. The registry is built on first use and shared by the decoding methods.
+func (s *Sinker) ProtoFiles() (*protoregistry.Files, error) {
+	decoder, err := s.outputDecoder()
+	if err != nil {
+		return nil, err
+	}
+
+	return decoder.files, nil
+}
+
+// DecodeOutput decodes the output of `data`, that is `data.Output.MapOutput`, into a dynamic
+// message using the Protobuf descriptors embedded in the package, letting generic handlers
+// work with any module without the generated Go types.
+func (s *Sinker) DecodeOutput(data *pbsubstreamsrpc.BlockScopedData) (*dynamicpb.Message, error) {
+	return s.DecodeAny(data.GetOutput().GetMapOutput())
+}
+
+// DecodeOutputJSON decodes the output of `data`, that is `data.Output.MapOutput`, into its
+// canonical Protobuf JSON form, compacted, see [Sinker.DecodeOutput].
+func (s *Sinker) DecodeOutputJSON(data *pbsubstreamsrpc.BlockScopedData) ([]byte, error) {
+	return s.DecodeAnyJSON(data.GetOutput().GetMapOutput())
+}
+
+// DecodeAny decodes `output` into a dynamic message of the type identified by its type URL,
+// using the Protobuf descriptors embedded in the package.
+func (s *Sinker) DecodeAny(output *anypb.Any) (*dynamicpb.Message, error) {
+	decoder, err := s.outputDecoder()
+	if err != nil {
+		return nil, err
+	}
+
+	return decoder.Decode(output)
+}
+
+// DecodeAnyJSON decodes `output` into its canonical Protobuf JSON form, compacted, see
+// [Sinker.DecodeAny].
+func (s *Sinker) DecodeAnyJSON(output *anypb.Any) ([]byte, error) {
+	decoder, err := s.outputDecoder()
+	if err != nil {
+		return nil, err
+	}
+
+	return decoder.DecodeJSON(output)
+}
+
+func (s *Sinker) outputDecoder() (*outputDecoder, error) {
+	s.decoderOnce.Do(func() {
+		s.decoder, s.decoderErr = newOutputDecoder(s.pkg)
+	})
+
+	return s.decoder, s.decoderErr
+}
@@ -0,0 +1,89 @@
+package sink
+
+import (
+	"testing"
+
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestSinker_DecodeOutput(t *testing.T) {
+	clock := &pbsubstreams.Clock{Id: "10a", Number: 10, Timestamp: &timestamppb.Timestamp{Seconds: 1700000000}}
+
+	tests := []struct {
+		name          string
+		output        *anypb.Any
+		expectedJSON  string
+		expectedError string
+	}{
+		{
+			"package message",
+			testClockOutput(t, clock),
+			`{"id":"10a","number":"10","timestamp":"2023-11-14T22:13:20Z"}`,
+			"",
+		},
+		{
+			"empty message",
+			testClockOutput(t, &pbsubstreams.Clock{}),
+			`{}`,
+			"",
+		},
+		{
+			"unknown message",
+			&anypb.Any{TypeUrl: "type.googleapis.com/acme.v1.Unknown"},
+			"",
+			`find message "acme.v1.Unknown" in package descriptors: `,
+		},
+		{
+			"not a message",
+			&anypb.Any{TypeUrl: "type.googleapis.com/sf.substreams.v1.Clock.id"},
+			"",
+			`"sf.substreams.v1.Clock.id" is not a message`,
+		},
+		{
+			"invalid bytes",
+			&anypb.Any{TypeUrl: "type.googleapis.com/sf.substreams.v1.Clock", Value: []byte{0x0a, 0x05}},
+			"",
+			`unmarshal "sf.substreams.v1.Clock": `,
+		},
+		{
+			"no output",
+			nil,
+			"",
+			"no output to decode",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sinker := newTestSinker(t)
+			data := &pbsubstreamsrpc.BlockScopedData{Output: &pbsubstreamsrpc.MapModuleOutput{Name: "kv_out", MapOutput: tt.output}}
+
+			content, err := sinker.DecodeOutputJSON(data)
+			if tt.expectedError != "" {
+				// Protobuf purposely randomizes the whitespaces of its own error messages
+				assert.ErrorContains(t, err, tt.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedJSON, string(content))
+
+			message, err := sinker.DecodeOutput(data)
+			require.NoError(t, err)
+			assert.Equal(t, "sf.substreams.v1.Clock", string(message.Descriptor().FullName()))
+		})
+	}
+}
+
+func TestSinker_ProtoFiles(t *testing.T) {
+	files, err := newTestSinker(t).ProtoFiles()
+	require.NoError(t, err)
+
+	_, err = files.FindDescriptorByName("sf.substreams.v1.Clock")
+	assert.NoError(t, err)
+}
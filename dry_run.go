@@ -0,0 +1,80 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// dryRunHandler is the handler used instead of the user handler in dry-run mode, it prints one
+// JSON line per message received, see [WithDryRun].
+type dryRunHandler struct {
+	encoder    *json.Encoder
+	decodeJSON func(output *anypb.Any) ([]byte, error)
+}
+
+type dryRunBlock struct {
+	Block       uint64          `json:"block"`
+	ID          string          `json:"id"`
+	Timestamp   time.Time       `json:"timestamp"`
+	Cursor      string          `json:"cursor"`
+	SizeBytes   int             `json:"size_bytes"`
+	Type        string          `json:"type"`
+	Output      json.RawMessage `json:"output,omitempty"`
+	DecodeError string          `json:"decode_error,omitempty"`
+}
+
+type dryRunUndo struct {
+	Undo           bool   `json:"undo"`
+	LastValidBlock uint64 `json:"last_valid_block"`
+	LastValidID    string `json:"last_valid_id"`
+	Cursor         string `json:"cursor"`
+}
+
+func newDryRunHandler(out io.Writer, decodeJSON func(output *anypb.Any) ([]byte, error)) *dryRunHandler {
+	return &dryRunHandler{encoder: json.NewEncoder(out), decodeJSON: decodeJSON}
+}
+
+func (h *dryRunHandler) HandleBlockScopedData(_ context.Context, data *pbsubstreamsrpc.BlockScopedData, _ *bool, cursor *Cursor) error {
+	output := data.GetOutput().GetMapOutput()
+	line := &dryRunBlock{
+		Block:     data.Clock.GetNumber(),
+		ID:        data.Clock.GetId(),
+		Timestamp: data.Clock.GetTimestamp().AsTime(),
+		Cursor:    cursor.String(),
+		SizeBytes: len(output.GetValue()),
+		Type:      output.GetTypeUrl(),
+	}
+
+	if decoded, err := h.decodeJSON(output); err != nil {
+		line.DecodeError = err.Error()
+	} else {
+		line.Output = decoded
+	}
+
+	if err := h.encoder.Encode(line); err != nil {
+		return fmt.Errorf("write dry-run output: %w", err)
+	}
+
+	return nil
+}
+
+func (h *dryRunHandler) HandleBlockUndoSignal(_ context.Context, undoSignal *pbsubstreamsrpc.BlockUndoSignal, cursor *Cursor) error {
+	line := &dryRunUndo{
+		Undo:           true,
+		LastValidBlock: undoSignal.LastValidBlock.GetNumber(),
+		LastValidID:    undoSignal.LastValidBlock.GetId(),
+		Cursor:         cursor.String(),
+	}
+
+	if err := h.encoder.Encode(line); err != nil {
+		return fmt.Errorf("write dry-run output: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func TestSinker_DryRun(t *testing.T) {
+	endpoint := newTestStreamServer(t, func(req *pbsubstreamsrpc.Request, stream pbsubstreamsrpc.Stream_BlocksServer) error {
+		data := testCursorDataResponse(1)
+		data.GetBlockScopedData().Output.MapOutput = testClockOutput(t, &pbsubstreams.Clock{Id: "out", Number: 42})
+		if err := stream.Send(data); err != nil {
+			return err
+		}
+
+		undo := testCursorDataResponse(0).GetBlockScopedData()
+		return stream.Send(&pbsubstreamsrpc.Response{Message: &pbsubstreamsrpc.Response_BlockUndoSignal{BlockUndoSignal: &pbsubstreamsrpc.BlockUndoSignal{
+			LastValidBlock:  &pbsubstreams.BlockRef{Id: undo.Clock.Id, Number: undo.Clock.Number},
+			LastValidCursor: undo.Cursor,
+		}}})
+	})
+
+	var out bytes.Buffer
+	sinker := newTestPlaintextSinker(t, endpoint, WithDryRun(&out))
+	sinker.pkg.ProtoFiles = testClockProtoFiles()
+
+	failing := func(ctx context.Context, data *pbsubstreamsrpc.BlockScopedData, isLive *bool, cursor *Cursor) error {
+		return errors.New("handler must not be called")
+	}
+	result := sinker.RunWithResult(context.Background(), nil, NewSinkerHandlers(failing, nil))
+	require.Equal(t, TerminationReasonCompleted, result.Reason, "%v", result.Err)
+
+	decoder := json.NewDecoder(&out)
+
+	var block map[string]any
+	require.NoError(t, decoder.Decode(&block))
+	assert.Equal(t, float64(1), block["block"])
+	assert.Equal(t, "1a", block["id"])
+	assert.Equal(t, "type.googleapis.com/sf.substreams.v1.Clock", block["type"])
+	assert.Equal(t, map[string]any{"id": "out", "number": "42"}, block["output"])
+	assert.NotZero(t, block["size_bytes"])
+
+	var undo map[string]any
+	require.NoError(t, decoder.Decode(&undo))
+	assert.Equal(t, true, undo["undo"])
+	assert.Equal(t, float64(0), undo["last_valid_block"])
+}
+
+func TestSinker_DryRun_UnknownType(t *testing.T) {
+	endpoint := newTestStreamServer(t, func(req *pbsubstreamsrpc.Request, stream pbsubstreamsrpc.Stream_BlocksServer) error {
+		data := testCursorDataResponse(1)
+		data.GetBlockScopedData().Output.MapOutput = &anypb.Any{TypeUrl: "type.googleapis.com/acme.v1.Unknown", Value: []byte{0x0a, 0x01, 0x61}}
+		return stream.Send(data)
+	})
+
+	var out bytes.Buffer
+	result := newTestPlaintextSinker(t, endpoint, WithDryRun(&out)).RunWithResult(context.Background(), nil, nil)
+	require.Equal(t, TerminationReasonCompleted, result.Reason, "%v", result.Err)
+
+	var block map[string]any
+	require.NoError(t, json.Unmarshal(out.Bytes(), &block))
+	assert.Nil(t, block["output"])
+	assert.Equal(t, float64(3), block["size_bytes"])
+	assert.Contains(t, block["decode_error"], `find message "acme.v1.Unknown" in package descriptors`)
+}
+
+func testClockProtoFiles() []*descriptorpb.FileDescriptorProto {
+	return []*descriptorpb.FileDescriptorProto{protodesc.ToFileDescriptorProto(pbsubstreams.File_sf_substreams_v1_clock_proto)}
+}
+
+func testClockOutput(t *testing.T, clock *pbsubstreams.Clock) *anypb.Any {
+	t.Helper()
+
+	output, err := anypb.New(clock)
+	require.NoError(t, err)
+
+	return output
+}
@@ -0,0 +1,249 @@
+package sink
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/streamingfast/substreams/client"
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+	"google.golang.org/grpc"
+)
+
+// errPrimaryEndpointAvailable is returned by [Sinker.doRequest] when the stream is interrupted to
+// switch back to the primary endpoint after the cool-down period.
+var errPrimaryEndpointAvailable = errors.New("primary endpoint cool-down elapsed")
+
+// Default failover policy, see [WithEndpointFailover].
+const (
+	defaultFailoverAfter   = 3
+	defaultPrimaryCoolDown = 5 * time.Minute
+)
+
+// Endpoint is a Substreams endpoint the [Sinker] can connect to, see [WithEndpoints].
+type Endpoint struct {
+	// Address is the endpoint's address in the form `<host>:<port>`.
+	Address string
+	// Insecure skips certificate validation on gRPC connection.
+	Insecure bool
+	// Plaintext establishes gRPC connection in plaintext.
+	Plaintext bool
+	// TLS customizes the TLS settings of this endpoint, when nil the [Sinker]'s TLS settings
+	// are used, see [WithTLSConfig].
+	TLS *TLSConfig
+	// AuthProvider provides the credentials for this endpoint, when nil the [Sinker]'s
+	// credentials are used.
+	AuthProvider AuthProvider
+}
+
+func (e *Endpoint) String() string {
+	return fmt.Sprintf("%s (insecure: %t, plaintext: %t, auth provider: %t, tls: %s)", e.Address, e.Insecure, e.Plaintext, e.AuthProvider != nil, tlsMode(e.TLS, e.Insecure, e.Plaintext))
+}
+
+func (e *Endpoint) validate() error {
+	if !endpointPortRegex.MatchString(e.Address) {
+		return fmt.Errorf("endpoint %q must be in the form '<host>:<port>'", e.Address)
+	}
+
+	if e.Insecure && e.Plaintext {
+		return fmt.Errorf("endpoint %q: insecure and plaintext are mutually exclusive", e.Address)
+	}
+
+	if e.TLS.isSet() && e.Plaintext {
+		return fmt.Errorf("endpoint %q: tls settings and plaintext are mutually exclusive", e.Address)
+	}
+
+	if err := e.TLS.Validate(); err != nil {
+		return fmt.Errorf("endpoint %q: %w", e.Address, err)
+	}
+
+	return nil
+}
+
+// endpointFailover tracks the endpoint currently used by the [Sinker], it moves to the next
+// endpoint after `failoverAfter` consecutive failures and back to the primary endpoint once
+// `primaryCoolDown` elapsed since the primary endpoint was left.
+type endpointFailover struct {
+	endpoints       []*Endpoint
+	failoverAfter   int
+	primaryCoolDown time.Duration
+	now             func() time.Time
+
+	mu            sync.Mutex
+	active        int
+	failures      int
+	primaryLeftAt time.Time
+}
+
+func newEndpointFailover(endpoints []*Endpoint, failoverAfter int, primaryCoolDown time.Duration) *endpointFailover {
+	return &endpointFailover{
+		endpoints:       endpoints,
+		failoverAfter:   failoverAfter,
+		primaryCoolDown: primaryCoolDown,
+		now:             time.Now,
+	}
+}
+
+func (f *endpointFailover) Active() (index int, endpoint *Endpoint) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.active, f.endpoints[f.active]
+}
+
+// RecordSuccess resets the consecutive failures count of the active endpoint.
+func (f *endpointFailover) RecordSuccess() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.failures = 0
+}
+
+// RecordFailure records a failure of the active endpoint and moves to the next endpoint if
+// the active one failed too many times in a row, returns true if the active endpoint changed.
+func (f *endpointFailover) RecordFailure() (rotated bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.failures++
+	if len(f.endpoints) == 1 || f.failures < f.failoverAfter {
+		return false
+	}
+
+	if f.active == 0 {
+		f.primaryLeftAt = f.now()
+	}
+
+	f.active = (f.active + 1) % len(f.endpoints)
+	f.failures = 0
+
+	return true
+}
+
+// PrimaryAvailable returns true if a fallback endpoint is active and the primary endpoint
+// cool-down elapsed.
+func (f *endpointFailover) PrimaryAvailable() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.primaryAvailable()
+}
+
+func (f *endpointFailover) primaryAvailable() bool {
+	return f.active != 0 && f.now().Sub(f.primaryLeftAt) >= f.primaryCoolDown
+}
+
+// RestorePrimary makes the primary endpoint active again if its cool-down elapsed, returns
+// true if the active endpoint changed.
+func (f *endpointFailover) RestorePrimary() (restored bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !f.primaryAvailable() {
+		return false
+	}
+
+	f.active = 0
+	f.failures = 0
+
+	return true
+}
+
+// endpointConnection is the connection to the active endpoint, it can be closed concurrently
+// when the [Sinker] terminates.
+type endpointConnection struct {
+	index        int
+	client       pbsubstreamsrpc.StreamClient
+	callOpts     []grpc.CallOption
+	headers      []string
+	authProvider AuthProvider
+	plaintext    bool
+
+	mu        sync.Mutex
+	closeFunc func() error
+}
+
+func (c *endpointConnection) Connected(index int) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.closeFunc != nil && c.index == index
+}
+
+func (c *endpointConnection) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closeFunc != nil {
+		c.closeFunc()
+		c.closeFunc = nil
+	}
+}
+
+// connect connects `connection` to `endpoint`, closing any previous connection first.
+func (s *Sinker) connect(connection *endpointConnection, index int, endpoint *Endpoint) error {
+	connection.Close()
+
+	authProvider := endpoint.AuthProvider
+	if authProvider == nil {
+		authProvider = s.authProvider
+	}
+
+	// Credentials are added to each request from the auth provider, so they can change over time
+	clientConfig := client.NewSubstreamsClientConfig(endpoint.Address, "", client.None, endpoint.Insecure, endpoint.Plaintext)
+	if authProvider == nil {
+		clientConfig = client.NewSubstreamsClientConfig(endpoint.Address, s.clientConfig.AuthToken(), s.clientConfig.AuthType(), endpoint.Insecure, endpoint.Plaintext)
+	}
+
+	tlsConfig := endpoint.TLS
+	if tlsConfig == nil {
+		tlsConfig = s.tlsConfig
+	}
+
+	ssClient, closeFunc, callOpts, headers, err := newSubstreamsClient(clientConfig, tlsConfig, s.dialOptions()...)
+	if err != nil {
+		return fmt.Errorf("new substreams client: %w", err)
+	}
+	callOpts = append(callOpts, s.callOptions()...)
+
+	// Header values are resolved on each connection so that values sourced from files can change over time
+	extraHeaders, err := parseHeaders(s.extraHeaders)
+	if err != nil {
+		closeFunc()
+		return fmt.Errorf("extra headers: %w", err)
+	}
+
+	if headers == nil {
+		headers = make(client.Headers)
+	}
+	headers.Append(extraHeaders)
+
+	connection.mu.Lock()
+	defer connection.mu.Unlock()
+
+	connection.index = index
+	connection.client = ssClient
+	connection.callOpts = callOpts
+	connection.headers = nil
+	if headers.IsSet() {
+		connection.headers = headers.ToArray()
+	}
+	connection.authProvider = authProvider
+	connection.plaintext = endpoint.Plaintext
+	connection.closeFunc = closeFunc
+
+	for i, other := range s.endpoints {
+		ActiveEndpoint.SetUint64(boolToUint64(i == index), other.Address)
+	}
+
+	return nil
+}
+
+func boolToUint64(value bool) uint64 {
+	if value {
+		return 1
+	}
+
+	return 0
+}
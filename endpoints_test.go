@@ -0,0 +1,89 @@
+package sink
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEndpointFailover(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+
+	primary, secondary, tertiary := &Endpoint{Address: "primary:443"}, &Endpoint{Address: "secondary:443"}, &Endpoint{Address: "tertiary:443"}
+	failover := newEndpointFailover([]*Endpoint{primary, secondary, tertiary}, 2, time.Minute)
+	failover.now = func() time.Time { return now }
+
+	active := func() *Endpoint {
+		_, endpoint := failover.Active()
+		return endpoint
+	}
+
+	assert.Equal(t, primary, active())
+	assert.False(t, failover.RecordFailure())
+	failover.RecordSuccess()
+	assert.False(t, failover.RecordFailure(), "success resets consecutive failures")
+	assert.True(t, failover.RecordFailure())
+	assert.Equal(t, secondary, active())
+
+	now = now.Add(30 * time.Second)
+	assert.False(t, failover.PrimaryAvailable())
+	assert.False(t, failover.RestorePrimary())
+
+	assert.False(t, failover.RecordFailure())
+	assert.True(t, failover.RecordFailure())
+	assert.Equal(t, tertiary, active())
+
+	now = now.Add(30 * time.Second)
+	assert.True(t, failover.PrimaryAvailable(), "cool-down counts from when the primary was left")
+	assert.True(t, failover.RestorePrimary())
+	assert.Equal(t, primary, active())
+	assert.False(t, failover.PrimaryAvailable())
+
+	assert.False(t, failover.RecordFailure())
+	assert.True(t, failover.RecordFailure())
+	assert.Equal(t, secondary, active())
+	assert.False(t, failover.PrimaryAvailable(), "cool-down restarts when the primary is left again")
+}
+
+func TestEndpointFailover_SingleEndpoint(t *testing.T) {
+	failover := newEndpointFailover([]*Endpoint{{Address: "primary:443"}}, 1, 0)
+
+	assert.False(t, failover.RecordFailure())
+	assert.False(t, failover.RecordFailure())
+	assert.False(t, failover.PrimaryAvailable())
+
+	index, _ := failover.Active()
+	assert.Equal(t, 0, index)
+}
+
+func TestWithEndpoints(t *testing.T) {
+	newSinker := func(opts ...Option) (*Sinker, error) {
+		config := NewDefaultSinkerConfig()
+		config.Endpoint = "localhost:9000"
+		config.ManifestPath = "testdata/substreams.yaml"
+		config.OutputModule = "kv_out"
+
+		return NewFromConfig(context.Background(), config, zlog, nil, opts...)
+	}
+
+	sinker, err := newSinker()
+	require.NoError(t, err)
+	assert.Equal(t, &Endpoint{Address: "localhost:9000"}, sinker.ActiveEndpoint())
+
+	primary := &Endpoint{Address: "primary:443"}
+	sinker, err = newSinker(WithEndpoints(primary, &Endpoint{Address: "secondary:9000", Plaintext: true}))
+	require.NoError(t, err)
+	assert.Equal(t, primary, sinker.ActiveEndpoint())
+
+	_, err = newSinker(WithEndpoints(primary, &Endpoint{Address: "secondary"}))
+	require.EqualError(t, err, `invalid endpoints: endpoint "secondary" must be in the form '<host>:<port>'`)
+
+	_, err = newSinker(WithEndpoints(&Endpoint{Address: "secondary:443", Insecure: true, Plaintext: true}))
+	require.EqualError(t, err, `invalid endpoints: endpoint "secondary:443": insecure and plaintext are mutually exclusive`)
+
+	_, err = newSinker(WithEndpointFailover(0, time.Minute))
+	require.EqualError(t, err, `invalid endpoint failover: failover after must be at least 1, got 0`)
+}
@@ -2,6 +2,138 @@ package sink
 
 import (
 	"errors"
+	"fmt"
+
+	"github.com/streamingfast/bstream"
+	"google.golang.org/grpc/codes"
 )
 
 var ErrBackOffExpired = errors.New("unable to complete work within backoff time limit")
+
+// ErrStoppedGracefully is the [shutter.Shutter] error of a [Sinker] stopped with [Sinker.StopGracefully].
+var ErrStoppedGracefully = errors.New("sinker stopped gracefully")
+
+// ErrGracefulStopTimeout is wrapped by the [shutter.Shutter] error of a [Sinker] that did not stop within
+// the timeout given to [Sinker.StopGracefully].
+var ErrGracefulStopTimeout = errors.New("graceful stop timed out")
+
+// errGracefulStop is returned by [Sinker.doRequest] when the stream was stopped by [Sinker.StopGracefully].
+var errGracefulStop = errors.New("graceful stop requested")
+
+// ErrHandlerFailed is matched, through [errors.Is], by the errors returned by the handler or
+// one of its optional callbacks, use [errors.As] with [HandlerError] for the details, see
+// [TerminationReasonHandlerError].
+var ErrHandlerFailed = errors.New("handler failed")
+
+// ErrStreamFailed is matched, through [errors.Is], by the non-retryable errors returned by the
+// Substreams endpoint, use [errors.As] with [StreamError] for the gRPC code, see
+// [TerminationReasonFailed].
+var ErrStreamFailed = errors.New("stream failed")
+
+// StreamError is a non-retryable error returned by the Substreams endpoint, it matches
+// [ErrStreamFailed] through [errors.Is].
+type StreamError struct {
+	// Code is the gRPC status code of the error.
+	Code codes.Code
+	Err  error
+}
+
+func (e *StreamError) Error() string {
+	if e.Code == codes.InvalidArgument {
+		return fmt.Sprintf("stream invalid: %s", e.Err)
+	}
+
+	return fmt.Sprintf("stream failure: %s", e.Err)
+}
+
+func (e *StreamError) Unwrap() error        { return e.Err }
+func (e *StreamError) Is(target error) bool { return target == ErrStreamFailed }
+
+// Message types, or callbacks, reported by [HandlerError.MessageType].
+const (
+	MessageTypeBlockScopedData             = "BlockScopedData"
+	MessageTypeBlockUndoSignal             = "BlockUndoSignal"
+	MessageTypeLivenessTransition          = "LivenessTransition"
+	MessageTypeBlockRangeSegmentCompletion = "BlockRangeSegmentCompletion"
+	MessageTypeBlockRangeCompletion        = "BlockRangeCompletion"
+	MessageTypeFlush                       = "Flush"
+	MessageTypeModuleHashChange            = "ModuleHashChange"
+)
+
+// HandlerError is an error returned by the handler or one of its optional callbacks, it
+// matches [ErrHandlerFailed] through [errors.Is]. A retryable error returned by the handler
+// is still retried, see `derr.NewRetryableError`.
+type HandlerError struct {
+	// MessageType is the message type or the callback that failed, one of the `MessageType*` constants.
+	MessageType string
+	// Block is the block being handled, or the block of the cursor given to the callback, nil if
+	// no block was handled yet.
+	Block bstream.BlockRef
+	Err   error
+}
+
+func (e *HandlerError) Error() string {
+	if e.Block == nil {
+		return fmt.Sprintf("handle %s: %s", e.MessageType, e.Err)
+	}
+
+	return fmt.Sprintf("handle %s at block %s: %s", e.MessageType, e.Block, e.Err)
+}
+
+func (e *HandlerError) Unwrap() error        { return e.Err }
+func (e *HandlerError) Is(target error) bool { return target == ErrHandlerFailed }
+
+// newHandlerError returns a [HandlerError] for `messageType`, the block being the block of `cursor`.
+func newHandlerError(messageType string, cursor *Cursor, err error) *HandlerError {
+	handlerErr := &HandlerError{MessageType: messageType, Err: err}
+	if !cursor.IsBlank() {
+		handlerErr.Block = cursor.Block()
+	}
+
+	return handlerErr
+}
+
+// BufferError is returned when a message received cannot be applied to the undo buffer, the
+// most common cause being an undo signal going deeper than the blocks already sent to the
+// handler, increase the undo buffer size if it happens.
+type BufferError struct {
+	// Undo is true when an undo signal went deeper than the last block sent to the handler, false
+	// when a block was received out of order.
+	Undo bool
+	// Block is the block received or the last valid block of the undo signal.
+	Block bstream.BlockRef
+	// ConflictingBlock is the most recent block buffered or the last block sent to the handler
+	// for an undo signal.
+	ConflictingBlock bstream.BlockRef
+}
+
+func (e *BufferError) Error() string {
+	if e.Undo {
+		return fmt.Sprintf("cannot undo down to last valid Block %s because we already sent you Block %s which is after last valid block", e.Block, e.ConflictingBlock)
+	}
+
+	return fmt.Sprintf("received new block scoped data (Block %s) whose height is lower or equal than our most recent block (Block %s)", e.Block, e.ConflictingBlock)
+}
+
+// CursorError is returned when a cursor cannot be decoded, see [NewCursor].
+type CursorError struct {
+	Cursor string
+	Err    error
+}
+
+func (e *CursorError) Error() string {
+	return fmt.Sprintf("decode %q: %s", e.Cursor, e.Err)
+}
+
+func (e *CursorError) Unwrap() error { return e.Err }
+
+// ManifestError is returned when the manifest cannot be read or its output module is invalid,
+// see [ReadManifestAndModule].
+type ManifestError struct {
+	ManifestPath string
+	OutputModule string
+	Err          error
+}
+
+func (e *ManifestError) Error() string { return e.Err.Error() }
+func (e *ManifestError) Unwrap() error { return e.Err }
@@ -0,0 +1,70 @@
+package sink
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/streamingfast/bstream"
+	"github.com/streamingfast/derr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+)
+
+func TestErrors_As(t *testing.T) {
+	t.Run("stream error", func(t *testing.T) {
+		err := fmt.Errorf("run: %w", &StreamError{Code: codes.Unauthenticated, Err: errors.New("bad token")})
+		assert.EqualError(t, err, "run: stream failure: bad token")
+		assert.ErrorIs(t, err, ErrStreamFailed)
+		assert.NotErrorIs(t, err, ErrHandlerFailed)
+
+		var streamErr *StreamError
+		require.ErrorAs(t, err, &streamErr)
+		assert.Equal(t, codes.Unauthenticated, streamErr.Code)
+
+		assert.EqualError(t, &StreamError{Code: codes.InvalidArgument, Err: errors.New("bad request")}, "stream invalid: bad request")
+	})
+
+	t.Run("handler error", func(t *testing.T) {
+		err := &HandlerError{MessageType: MessageTypeBlockScopedData, Block: bstream.NewBlockRef("2a", 2), Err: derr.NewRetryableError(errors.New("database unavailable"))}
+		assert.EqualError(t, err, "handle BlockScopedData at block #2 (2a): database unavailable (retryable)")
+		assert.ErrorIs(t, err, ErrHandlerFailed)
+
+		var retryableErr *derr.RetryableError
+		assert.ErrorAs(t, err, &retryableErr, "retryable handler errors are still retried")
+
+		assert.EqualError(t, newHandlerError(MessageTypeFlush, NewBlankCursor(), errors.New("disk full")), "handle Flush: disk full")
+	})
+
+	t.Run("buffer error", func(t *testing.T) {
+		buffer := newBlockDataBuffer(1)
+		_, err := buffer.HandleBlockScopedData(blockScopedData("2a", 0))
+		require.NoError(t, err)
+		_, err = buffer.HandleBlockScopedData(blockScopedData("3a", 0))
+		require.NoError(t, err)
+
+		var bufferErr *BufferError
+		require.ErrorAs(t, buffer.HandleBlockUndoSignal(msgBlockUndoSignal("1a").blockUndoSignal), &bufferErr)
+		assert.True(t, bufferErr.Undo)
+		assert.Equal(t, uint64(1), bufferErr.Block.Num())
+		assert.Equal(t, uint64(2), bufferErr.ConflictingBlock.Num())
+	})
+
+	t.Run("cursor error", func(t *testing.T) {
+		_, err := NewCursor("invalid")
+
+		var cursorErr *CursorError
+		require.ErrorAs(t, err, &cursorErr)
+		assert.Equal(t, "invalid", cursorErr.Cursor)
+	})
+
+	t.Run("manifest error", func(t *testing.T) {
+		_, _, _, err := ReadManifestAndModule("testdata/substreams.yaml", "", nil, "unknown_out", IgnoreOutputModuleType, false, zlog)
+
+		var manifestErr *ManifestError
+		require.ErrorAs(t, err, &manifestErr)
+		assert.Equal(t, "testdata/substreams.yaml", manifestErr.ManifestPath)
+		assert.Equal(t, "unknown_out", manifestErr.OutputModule)
+	})
+}
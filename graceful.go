@@ -0,0 +1,76 @@
+package sink
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// StopGracefully stops the sinker at a block boundary, typically upon receiving `SIGTERM`,
+// instead of canceling the context given to [Sinker.Run] which can interrupt the handler in
+// the middle of its work.
+//
+// No new message is read from the stream, the handler call in progress, if any, completes
+// normally. Then [SinkerFlushHandler.HandleFlush] is called if implemented by the handler,
+// the cursor of the last block handled is saved to the [CursorStore] configured with
+// [WithCursorStore], if any, and given to the callback configured with
+// [WithGracefulStopCallback], and the sinker terminates with [ErrStoppedGracefully] as its
+// [shutter.Shutter] error.
+//
+// It blocks until the sinker terminated. If it did not terminate within `timeout`, it's shut
+// down with an error wrapping [ErrGracefulStopTimeout] and that error is returned.
+func (s *Sinker) StopGracefully(timeout time.Duration) error {
+	s.logger.Info("graceful stop requested, waiting for in-flight handler call to complete", zap.Duration("timeout", timeout))
+	s.requestGracefulStop()
+
+	s.state.update(func(state *runtimeState) {
+		if state.cancelStream != nil {
+			state.cancelStream(errGracefulStop)
+		}
+	})
+
+	select {
+	case <-s.Terminated():
+		return nil
+	case <-time.After(timeout):
+		err := fmt.Errorf("%w after %s", ErrGracefulStopTimeout, timeout)
+		s.Shutdown(err)
+
+		return err
+	}
+}
+
+func (s *Sinker) gracefulStopRequested() bool {
+	return s.gracefulStop.Err() != nil
+}
+
+// stopGracefully completes the graceful stop once the stream has been stopped, `cursor` being
+// the cursor to restart from if no block was handled.
+func (s *Sinker) stopGracefully(ctx context.Context, handler SinkerHandler, cursor *Cursor) error {
+	s.state.mu.Lock()
+	if s.state.lastHandledCursor != nil {
+		cursor = s.state.lastHandledCursor
+	}
+	s.state.mu.Unlock()
+
+	if v, ok := handler.(SinkerFlushHandler); ok {
+		s.logger.Info("substreams handler has flush callback defined, calling it")
+
+		if err := v.HandleFlush(ctx, cursor); err != nil {
+			return newHandlerError(MessageTypeFlush, cursor, err)
+		}
+	}
+
+	if err := s.saveCursor(ctx, cursor); err != nil {
+		return err
+	}
+
+	s.logger.Info("substreams stopped gracefully", zap.Stringer("cursor", cursor))
+	if s.gracefulStopCallback != nil {
+		s.gracefulStopCallback(cursor)
+	}
+
+	return nil
+}
@@ -0,0 +1,156 @@
+package sink
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/streamingfast/bstream"
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func TestSinker_StopGracefully(t *testing.T) {
+	endpoint := newTestStreamServer(t, func(req *pbsubstreamsrpc.Request, stream pbsubstreamsrpc.Stream_BlocksServer) error {
+		for num := uint64(1); num <= 2; num++ {
+			if err := stream.Send(testCursorDataResponse(num)); err != nil {
+				return err
+			}
+		}
+
+		<-stream.Context().Done()
+		return nil
+	})
+
+	var flushedCursor, callbackCursor *Cursor
+	inHandler := make(chan struct{})
+	handler := &testFlushHandler{
+		SinkerHandler: NewSinkerHandlers(
+			func(ctx context.Context, data *pbsubstreamsrpc.BlockScopedData, isLive *bool, cursor *Cursor) error {
+				if data.Clock.Number == 2 {
+					close(inHandler)
+					time.Sleep(50 * time.Millisecond)
+				}
+
+				return nil
+			},
+			func(ctx context.Context, undoSignal *pbsubstreamsrpc.BlockUndoSignal, cursor *Cursor) error {
+				return nil
+			},
+		),
+		flush: func(cursor *Cursor) error {
+			flushedCursor = cursor
+			return nil
+		},
+	}
+
+	sinker := newTestPlaintextSinker(t, endpoint, WithGracefulStopCallback(func(cursor *Cursor) { callbackCursor = cursor }))
+	go sinker.Run(context.Background(), nil, handler)
+
+	<-inHandler
+	require.NoError(t, sinker.StopGracefully(5*time.Second))
+
+	assert.ErrorIs(t, sinker.Err(), ErrStoppedGracefully)
+	require.NotNil(t, flushedCursor)
+	assert.Equal(t, uint64(2), flushedCursor.Block().Num(), "in-flight handler call completed")
+	assert.Equal(t, flushedCursor, callbackCursor)
+}
+
+func TestSinker_StopGracefully_Timeout(t *testing.T) {
+	endpoint := newTestStreamServer(t, func(req *pbsubstreamsrpc.Request, stream pbsubstreamsrpc.Stream_BlocksServer) error {
+		if err := stream.Send(testCursorDataResponse(1)); err != nil {
+			return err
+		}
+
+		<-stream.Context().Done()
+		return nil
+	})
+
+	inHandler := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+
+	sinker := newTestPlaintextSinker(t, endpoint)
+	go sinker.Run(context.Background(), nil, NewSinkerHandlers(
+		func(ctx context.Context, data *pbsubstreamsrpc.BlockScopedData, isLive *bool, cursor *Cursor) error {
+			close(inHandler)
+			<-release
+			return nil
+		},
+		func(ctx context.Context, undoSignal *pbsubstreamsrpc.BlockUndoSignal, cursor *Cursor) error {
+			return nil
+		},
+	))
+
+	<-inHandler
+	err := sinker.StopGracefully(20 * time.Millisecond)
+	require.ErrorIs(t, err, ErrGracefulStopTimeout)
+	assert.ErrorIs(t, sinker.Err(), ErrGracefulStopTimeout)
+}
+
+type testFlushHandler struct {
+	SinkerHandler
+
+	flush func(cursor *Cursor) error
+}
+
+func (h *testFlushHandler) HandleFlush(ctx context.Context, cursor *Cursor) error {
+	return h.flush(cursor)
+}
+
+// newTestStreamServer serves `blocks` as the Substreams `Blocks` endpoint in plaintext and
+// returns its address.
+func newTestStreamServer(t *testing.T, blocks func(req *pbsubstreamsrpc.Request, stream pbsubstreamsrpc.Stream_BlocksServer) error) string {
+	t.Helper()
+
+	server := grpc.NewServer()
+	pbsubstreamsrpc.RegisterStreamServer(server, testStreamServer(blocks))
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	return listener.Addr().String()
+}
+
+type testStreamServer func(req *pbsubstreamsrpc.Request, stream pbsubstreamsrpc.Stream_BlocksServer) error
+
+func (f testStreamServer) Blocks(req *pbsubstreamsrpc.Request, stream pbsubstreamsrpc.Stream_BlocksServer) error {
+	return f(req, stream)
+}
+
+func newTestPlaintextSinker(t *testing.T, endpoint string, opts ...Option) *Sinker {
+	t.Helper()
+
+	config := NewDefaultSinkerConfig()
+	config.Endpoint = endpoint
+	config.Plaintext = true
+	config.ManifestPath = "testdata/substreams.yaml"
+	config.OutputModule = "kv_out"
+	config.UndoBufferSize = 0
+
+	sinker, err := NewFromConfig(context.Background(), config, zlog, ztracer, opts...)
+	require.NoError(t, err)
+
+	return sinker
+}
+
+// testCursorDataResponse returns a data message for block `num` with a valid cursor.
+func testCursorDataResponse(num uint64) *pbsubstreamsrpc.Response {
+	block := bstream.NewBlockRef(fmt.Sprintf("%da", num), num)
+	cursor := &bstream.Cursor{Step: bstream.StepNew, Block: block, HeadBlock: block, LIB: bstream.NewBlockRef("0a", 0)}
+
+	return &pbsubstreamsrpc.Response{Message: &pbsubstreamsrpc.Response_BlockScopedData{BlockScopedData: &pbsubstreamsrpc.BlockScopedData{
+		Output: &pbsubstreamsrpc.MapModuleOutput{Name: "kv_out", MapOutput: &anypb.Any{}},
+		Clock:  &pbsubstreams.Clock{Id: block.ID(), Number: num},
+		Cursor: cursor.ToOpaque(),
+	}}}
+}
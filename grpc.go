@@ -0,0 +1,249 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/streamingfast/dgrpc"
+	"github.com/streamingfast/substreams/client"
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/encoding"
+	_ "google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/stats"
+)
+
+// Compressors accepted by [WithCompression].
+const (
+	CompressionGzip = "gzip"
+	CompressionZstd = "zstd"
+)
+
+// zstdDecoderMaxMemory caps the memory used to decompress a zstd message, protecting against
+// compression bombs, messages bigger than this once decompressed are rejected.
+const zstdDecoderMaxMemory = 256 * 1024 * 1024
+
+var registerZstdCompressorOnce sync.Once
+
+// registerZstdCompressor registers the zstd compressor in gRPC's compressors registry. The
+// registry being global to the process, it's only done once a sinker is configured to use zstd,
+// see [WithCompression].
+func registerZstdCompressor() {
+	registerZstdCompressorOnce.Do(func() {
+		// Another library might have registered its own implementation already, in which case we keep it
+		if encoding.GetCompressor(CompressionZstd) == nil {
+			encoding.RegisterCompressor(newZstdCompressor())
+		}
+	})
+}
+
+// newSubstreamsClient is [client.NewSubstreamsClient] supporting custom TLS settings and extra
+// dial options. When any of them is provided, the connection is established directly and the
+// credentials of `config` are returned as headers.
+func newSubstreamsClient(config *client.SubstreamsClientConfig, tlsConfig *TLSConfig, dialOptions ...grpc.DialOption) (cli pbsubstreamsrpc.StreamClient, closeFunc func() error, callOpts []grpc.CallOption, headers client.Headers, err error) {
+	if !tlsConfig.isSet() && len(dialOptions) == 0 {
+		return client.NewSubstreamsClient(config)
+	}
+
+	if !endpointPortRegex.MatchString(config.Endpoint()) {
+		return nil, nil, nil, nil, fmt.Errorf("invalid endpoint %q: endpoint's suffix must be a valid port in the form ':<port>', port 443 is usually the right one to use", config.Endpoint())
+	}
+
+	if config.Insecure() && config.PlainText() {
+		return nil, nil, nil, nil, fmt.Errorf("option --insecure and --plaintext are mutually exclusive, they cannot be both specified at the same time")
+	}
+
+	transportCredentials := insecure.NewCredentials()
+	if !config.PlainText() {
+		transportConfig, err := tlsConfig.load(config.Insecure())
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
+
+		transportCredentials = credentials.NewTLS(transportConfig)
+	}
+
+	conn, err := dgrpc.NewExternalClient(config.Endpoint(), append([]grpc.DialOption{grpc.WithTransportCredentials(transportCredentials)}, dialOptions...)...)
+	if err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("unable to create external gRPC client: %w", err)
+	}
+
+	// Like [client.NewSubstreamsClient], credentials are never sent in plaintext
+	headers = make(client.Headers)
+	if token := config.AuthToken(); token != "" && !config.PlainText() {
+		switch config.AuthType() {
+		case client.JWT:
+			headers["authorization"] = "Bearer " + token
+		case client.ApiKey:
+			headers[client.ApiKeyHeader] = token
+		}
+	}
+
+	return pbsubstreamsrpc.NewStreamClient(conn), conn.Close, nil, headers, nil
+}
+
+// dialOptions returns the dial options of the connection to the Substreams endpoint, none when
+// no dial related setting is configured so that [client.NewSubstreamsClient] connects.
+func (s *Sinker) dialOptions() (options []grpc.DialOption) {
+	// The xDS bootstrap is only supported by [client.NewSubstreamsClient], so we let it
+	// connect when it's configured, wire size is not recorded in that case
+	if os.Getenv("GRPC_XDS_BOOTSTRAP") != "" {
+		return nil
+	}
+
+	// Wire size only differs from the decoded size when the stream is compressed
+	if s.compression != "" {
+		options = append(options, grpc.WithStatsHandler(wireSizeStatsHandler{}))
+	}
+
+	if s.keepAlive != nil {
+		options = append(options, grpc.WithKeepaliveParams(*s.keepAlive))
+	}
+
+	return options
+}
+
+// callOptions returns the call options of the Substreams stream.
+func (s *Sinker) callOptions() (options []grpc.CallOption) {
+	if s.maxRecvMessageSize > 0 {
+		options = append(options, grpc.MaxCallRecvMsgSize(s.maxRecvMessageSize))
+	}
+
+	if s.compression != "" {
+		options = append(options, grpc.UseCompressor(s.compression))
+	}
+
+	return options
+}
+
+type grpcSettingsStringer struct {
+	keepAlive          *keepalive.ClientParameters
+	maxRecvMessageSize int
+	compression        string
+}
+
+func (s *grpcSettingsStringer) String() string {
+	var settings []string
+	if s.keepAlive != nil {
+		settings = append(settings, fmt.Sprintf("keepalive: %s (timeout %s)", s.keepAlive.Time, s.keepAlive.Timeout))
+	}
+
+	if s.maxRecvMessageSize > 0 {
+		settings = append(settings, fmt.Sprintf("max receive message size: %d", s.maxRecvMessageSize))
+	}
+
+	if s.compression != "" {
+		settings = append(settings, "compression: "+s.compression)
+	}
+
+	if len(settings) == 0 {
+		return "defaults"
+	}
+
+	return strings.Join(settings, ", ")
+}
+
+// wireSizeStatsHandler records the size of received messages as seen on the wire, before
+// decompression, in [MessageWireSizeBytes].
+type wireSizeStatsHandler struct{}
+
+func (wireSizeStatsHandler) TagRPC(ctx context.Context, _ *stats.RPCTagInfo) context.Context {
+	return ctx
+}
+
+func (wireSizeStatsHandler) HandleRPC(_ context.Context, rpcStats stats.RPCStats) {
+	if payload, ok := rpcStats.(*stats.InPayload); ok {
+		MessageWireSizeBytes.AddInt(payload.WireLength)
+	}
+}
+
+func (wireSizeStatsHandler) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (wireSizeStatsHandler) HandleConn(context.Context, stats.ConnStats) {}
+
+// zstdCompressor is a gRPC [encoding.Compressor] using zstd. Messages are compressed in a single
+// pass and decompressed as a stream, so gRPC's maximum receive message size applies while
+// decompressing, both being safe for concurrent use.
+type zstdCompressor struct {
+	encoder  *zstd.Encoder
+	decoders sync.Pool
+}
+
+func newZstdCompressor() *zstdCompressor {
+	// Errors are only possible on invalid options
+	encoder, _ := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+
+	return &zstdCompressor{encoder: encoder}
+}
+
+func (c *zstdCompressor) Name() string {
+	return CompressionZstd
+}
+
+func (c *zstdCompressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	return &zstdWriteCloser{encoder: c.encoder, writer: w}, nil
+}
+
+func (c *zstdCompressor) Decompress(r io.Reader) (io.Reader, error) {
+	decoder, _ := c.decoders.Get().(*zstd.Decoder)
+	if decoder == nil {
+		// A single goroutine decodes synchronously, so a decoder not read to the end leaks nothing
+		var err error
+		if decoder, err = zstd.NewReader(r, zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxMemory(zstdDecoderMaxMemory)); err != nil {
+			return nil, err
+		}
+	} else if err := decoder.Reset(r); err != nil {
+		return nil, err
+	}
+
+	return &zstdReader{decoder: decoder, pool: &c.decoders}, nil
+}
+
+// zstdReader reads a decompressed message, its decoder is returned to the pool once the
+// message has been fully read.
+type zstdReader struct {
+	decoder *zstd.Decoder
+	pool    *sync.Pool
+}
+
+func (r *zstdReader) Read(p []byte) (int, error) {
+	if r.decoder == nil {
+		return 0, io.EOF
+	}
+
+	n, err := r.decoder.Read(p)
+	if err == io.EOF {
+		// Drops the reference to the compressed message before pooling the decoder
+		r.decoder.Reset(nil)
+		r.pool.Put(r.decoder)
+		r.decoder = nil
+	}
+
+	return n, err
+}
+
+type zstdWriteCloser struct {
+	encoder *zstd.Encoder
+	writer  io.Writer
+	buffer  bytes.Buffer
+}
+
+func (w *zstdWriteCloser) Write(p []byte) (int, error) {
+	return w.buffer.Write(p)
+}
+
+func (w *zstdWriteCloser) Close() error {
+	_, err := w.writer.Write(w.encoder.EncodeAll(w.buffer.Bytes(), nil))
+	return err
+}
@@ -0,0 +1,163 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/streamingfast/substreams/client"
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/stats"
+	"google.golang.org/grpc/status"
+)
+
+func Test_zstdCompressor(t *testing.T) {
+	compressor := newZstdCompressor()
+	payload := bytes.Repeat([]byte("substreams"), 1000)
+
+	var compressed bytes.Buffer
+	writer, err := compressor.Compress(&compressed)
+	require.NoError(t, err)
+	_, err = writer.Write(payload)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	assert.Less(t, compressed.Len(), len(payload))
+
+	// Decompressed twice to go through a pooled decoder
+	for i := 0; i < 2; i++ {
+		reader, err := compressor.Decompress(bytes.NewReader(compressed.Bytes()))
+		require.NoError(t, err)
+		decompressed, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Equal(t, payload, decompressed)
+	}
+
+	// Frame header declaring a 1 TiB single segment content followed by a 16 bytes RLE block
+	bomb := []byte{0x28, 0xb5, 0x2f, 0xfd, 0xe0, 0, 0, 0, 0, 0, 1, 0, 0, 0x83, 0, 0, 'a'}
+	reader, err := compressor.Decompress(bytes.NewReader(bomb))
+	if err == nil {
+		_, err = io.ReadAll(reader)
+	}
+	assert.ErrorIs(t, err, zstd.ErrDecoderSizeExceeded)
+}
+
+func Test_registerZstdCompressor(t *testing.T) {
+	WithCompression(CompressionZstd)
+	assert.NotNil(t, encoding.GetCompressor(CompressionZstd))
+}
+
+func Test_grpcSettingsStringer(t *testing.T) {
+	assert.Equal(t, "defaults", (&grpcSettingsStringer{}).String())
+	assert.Equal(t, "keepalive: 30s (timeout 10s), max receive message size: 1024, compression: zstd", (&grpcSettingsStringer{
+		keepAlive:          &keepalive.ClientParameters{Time: 30 * time.Second, Timeout: 10 * time.Second},
+		maxRecvMessageSize: 1024,
+		compression:        CompressionZstd,
+	}).String())
+}
+
+func TestWithCompression(t *testing.T) {
+	newSinker := func(opts ...Option) (*Sinker, error) {
+		config := NewDefaultSinkerConfig()
+		config.Endpoint = "localhost:9000"
+		config.ManifestPath = "testdata/substreams.yaml"
+		config.OutputModule = "kv_out"
+
+		return NewFromConfig(context.Background(), config, zlog, nil, opts...)
+	}
+
+	sinker, err := newSinker(WithCompression(CompressionGzip), WithMaxRecvMessageSize(1024), WithKeepAlive(time.Minute, 5*time.Second))
+	require.NoError(t, err)
+	assert.Len(t, sinker.callOptions(), 2)
+	assert.Len(t, sinker.dialOptions(), 2)
+
+	effective := sinker.EffectiveConfig()
+	assert.Equal(t, CompressionGzip, effective.GRPCCompression)
+	assert.Equal(t, 1024, effective.GRPCMaxRecvMessageSize)
+	assert.Equal(t, Duration(time.Minute), effective.GRPCKeepAliveTime)
+	assert.Equal(t, Duration(5*time.Second), effective.GRPCKeepAliveTimeout)
+
+	// Without dial settings, the upstream client connects
+	defaults, err := newSinker(WithMaxRecvMessageSize(1024))
+	require.NoError(t, err)
+	assert.Nil(t, defaults.dialOptions())
+
+	t.Setenv("GRPC_XDS_BOOTSTRAP", "bootstrap.json")
+	assert.Nil(t, sinker.dialOptions())
+
+	_, err = newSinker(WithCompression("brotli"))
+	require.EqualError(t, err, `invalid compression: unknown compressor "brotli", accepted values are "gzip" and "zstd"`)
+
+	_, err = newSinker(WithMaxRecvMessageSize(-1))
+	require.EqualError(t, err, `invalid max receive message size: must be positive, got -1`)
+}
+
+func Test_newSubstreamsClient_Compression(t *testing.T) {
+	statsHandler := &compressionStatsHandler{}
+	server := grpc.NewServer(grpc.StatsHandler(statsHandler))
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go server.Serve(listener)
+	defer server.Stop()
+
+	registerZstdCompressor()
+	sinker := &Sinker{compression: CompressionZstd, maxRecvMessageSize: 1024}
+
+	config := client.NewSubstreamsClientConfig(listener.Addr().String(), "", client.None, false, true)
+	ssClient, closeFunc, callOpts, _, err := newSubstreamsClient(config, nil, sinker.dialOptions()...)
+	require.NoError(t, err)
+	defer closeFunc()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := ssClient.Blocks(ctx, &pbsubstreamsrpc.Request{}, append(callOpts, sinker.callOptions()...)...)
+	if err == nil {
+		_, err = stream.Recv()
+	}
+
+	// No service is registered, so reaching the server is proven by an `Unimplemented` error
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+	assert.Equal(t, CompressionZstd, statsHandler.Compression())
+}
+
+// compressionStatsHandler records the compression of the last request received by a server.
+type compressionStatsHandler struct {
+	mu          sync.Mutex
+	compression string
+}
+
+func (h *compressionStatsHandler) Compression() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.compression
+}
+
+func (h *compressionStatsHandler) TagRPC(ctx context.Context, _ *stats.RPCTagInfo) context.Context {
+	return ctx
+}
+
+func (h *compressionStatsHandler) HandleRPC(_ context.Context, rpcStats stats.RPCStats) {
+	if header, ok := rpcStats.(*stats.InHeader); ok {
+		h.mu.Lock()
+		h.compression = header.Compression
+		h.mu.Unlock()
+	}
+}
+
+func (h *compressionStatsHandler) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (h *compressionStatsHandler) HandleConn(context.Context, stats.ConnStats) {}
@@ -0,0 +1,125 @@
+package sink
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Header value prefixes used to source a header value from somewhere else than the
+// header itself, see [WithExtraHeaders].
+const (
+	HeaderValueEnvPrefix  = "@env:"
+	HeaderValueFilePrefix = "@file:"
+)
+
+// sensitiveHeaderKeywords are the keywords that, when found in a header key, mark the header
+// as sensitive, its value is then redacted when logged.
+var sensitiveHeaderKeywords = []string{"authorization", "cookie", "token", "secret", "password", "key", "auth"}
+
+// parseHeaders parses `headers` in the form `key: value` and resolves their values, see
+// [WithExtraHeaders] for the accepted syntax. All errors found are reported at once.
+func parseHeaders(headers []string) (map[string]string, error) {
+	if len(headers) == 0 {
+		return nil, nil
+	}
+
+	var errs []error
+	result := make(map[string]string, len(headers))
+	for _, header := range headers {
+		key, value, err := parseHeader(header)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		resolved, err := resolveHeaderValue(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("header %q: %w", key, err))
+			continue
+		}
+
+		result[key] = resolved
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return result, nil
+}
+
+// parseHeader splits `header` on its first colon, so values can contain colons themselves.
+// The key is lower cased as gRPC metadata keys are case insensitive.
+func parseHeader(header string) (key string, value string, err error) {
+	key, value, found := strings.Cut(header, ":")
+	key = strings.ToLower(strings.TrimSpace(key))
+
+	if !found || key == "" {
+		return "", "", fmt.Errorf("header %q must be in the form 'key: value'", header)
+	}
+
+	return key, strings.TrimSpace(value), nil
+}
+
+func resolveHeaderValue(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, HeaderValueEnvPrefix):
+		name := strings.TrimPrefix(value, HeaderValueEnvPrefix)
+
+		resolved, found := os.LookupEnv(name)
+		if !found {
+			return "", fmt.Errorf("environment variable %q is not set", name)
+		}
+
+		return resolved, nil
+
+	case strings.HasPrefix(value, HeaderValueFilePrefix):
+		path := strings.TrimPrefix(value, HeaderValueFilePrefix)
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("read value file: %w", err)
+		}
+
+		return strings.TrimSpace(string(content)), nil
+
+	case strings.HasPrefix(value, "@@"):
+		return value[1:], nil
+	}
+
+	return value, nil
+}
+
+// redactHeaders returns `headers` with the value of sensitive headers replaced by `<redacted>`,
+// values sourced from an environment variable or a file are kept as they hold no secret.
+func redactHeaders(headers []string) []string {
+	if len(headers) == 0 {
+		return nil
+	}
+
+	redacted := make([]string, len(headers))
+	for i, header := range headers {
+		key, value, err := parseHeader(header)
+		if err != nil || !isSensitiveHeader(key) || strings.HasPrefix(value, HeaderValueEnvPrefix) || strings.HasPrefix(value, HeaderValueFilePrefix) {
+			redacted[i] = header
+			continue
+		}
+
+		redacted[i] = key + ": <redacted>"
+	}
+
+	return redacted
+}
+
+func isSensitiveHeader(key string) bool {
+	key = strings.ToLower(key)
+	for _, keyword := range sensitiveHeaderKeywords {
+		if strings.Contains(key, keyword) {
+			return true
+		}
+	}
+
+	return false
+}
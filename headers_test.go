@@ -0,0 +1,80 @@
+package sink
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseHeaders(t *testing.T) {
+	t.Setenv("SINK_TEST_HEADER", "from-env")
+
+	valueFile := filepath.Join(t.TempDir(), "value")
+	require.NoError(t, os.WriteFile(valueFile, []byte("from-file\n"), 0600))
+
+	tests := []struct {
+		name      string
+		headers   []string
+		want      map[string]string
+		expectErr string
+	}{
+		{"none", nil, nil, ""},
+		{"simple", []string{"x-a: b"}, map[string]string{"x-a": "b"}, ""},
+		{"key lower cased and trimmed", []string{"  X-A  :  b  "}, map[string]string{"x-a": "b"}, ""},
+		{"value with colons", []string{"x-url: https://example.com:443/path"}, map[string]string{"x-url": "https://example.com:443/path"}, ""},
+		{"empty value", []string{"x-a:"}, map[string]string{"x-a": ""}, ""},
+		{"from env", []string{"x-key: @env:SINK_TEST_HEADER"}, map[string]string{"x-key": "from-env"}, ""},
+		{"from file", []string{"x-key: @file:" + valueFile}, map[string]string{"x-key": "from-file"}, ""},
+		{"escaped at", []string{"x-a: @@env:literal"}, map[string]string{"x-a": "@env:literal"}, ""},
+		{"no colon", []string{"x-a"}, nil, `header "x-a" must be in the form 'key: value'`},
+		{"no key", []string{": b"}, nil, `header ": b" must be in the form 'key: value'`},
+		{"env missing", []string{"x-key: @env:SINK_TEST_HEADER_MISSING"}, nil, `header "x-key": environment variable "SINK_TEST_HEADER_MISSING" is not set`},
+		{"all errors", []string{"x-a", "x-b: @env:SINK_TEST_HEADER_MISSING"}, nil, "header \"x-a\" must be in the form 'key: value'\nheader \"x-b\": environment variable \"SINK_TEST_HEADER_MISSING\" is not set"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseHeaders(tt.headers)
+			if tt.expectErr != "" {
+				require.EqualError(t, err, tt.expectErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_redactHeaders(t *testing.T) {
+	assert.Nil(t, redactHeaders(nil))
+	assert.Equal(t, []string{
+		"x-trace-id: abc",
+		"authorization: <redacted>",
+		"x-api-key: <redacted>",
+		"X-Session-Token: @env:SESSION_TOKEN",
+		"x-secret: @file:/run/secret",
+		"invalid",
+	}, redactHeaders([]string{
+		"x-trace-id: abc",
+		"Authorization: Bearer abc",
+		"x-api-key: abc",
+		"X-Session-Token: @env:SESSION_TOKEN",
+		"x-secret: @file:/run/secret",
+		"invalid",
+	}))
+}
+
+func TestNewFromConfig_InvalidHeaders(t *testing.T) {
+	config := NewDefaultSinkerConfig()
+	config.Endpoint = "localhost:9000"
+	config.ManifestPath = "testdata/substreams.yaml"
+	config.OutputModule = "kv_out"
+	config.Headers = []string{"x-key: @env:SINK_TEST_HEADER_MISSING"}
+
+	_, err := NewFromConfig(context.Background(), config, zlog, nil)
+	require.EqualError(t, err, `invalid extra headers: header "x-key": environment variable "SINK_TEST_HEADER_MISSING" is not set`)
+}
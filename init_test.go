@@ -2,7 +2,7 @@ package sink
 
 import "github.com/streamingfast/logging"
 
-var zlog, _ = logging.PackageLogger("sink-tests", "github.com/streamingfast/substreams-sink/tests")
+var zlog, ztracer = logging.PackageLogger("sink-tests", "github.com/streamingfast/substreams-sink/tests")
 
 func init() {
 	logging.InstantiateLoggers()
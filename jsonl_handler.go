@@ -0,0 +1,369 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+	"go.uber.org/zap"
+)
+
+const (
+	jsonlCommittedSuffix   = ".jsonl"
+	jsonlUncommittedSuffix = ".jsonl.partial"
+	jsonlCursorFile        = "cursor.json"
+)
+
+// JSONLOption configures a [JSONLHandler].
+type JSONLOption func(h *JSONLHandler)
+
+// WithJSONLBlocksPerFile partitions files by block range, a file holds the blocks of a single
+// `[k*blocksPerFile, (k+1)*blocksPerFile)` range. Defaults to 1000, 0 disables partitioning by
+// block range.
+func WithJSONLBlocksPerFile(blocksPerFile uint64) JSONLOption {
+	return func(h *JSONLHandler) {
+		h.blocksPerFile = blocksPerFile
+	}
+}
+
+// WithJSONLMaxFileSize partitions files by size, a new file is started once the current one
+// reaches `maxBytes`. Disabled by default.
+func WithJSONLMaxFileSize(maxBytes int64) JSONLOption {
+	return func(h *JSONLHandler) {
+		h.maxFileSize = maxBytes
+	}
+}
+
+// JSONLHandler is a ready-made [SinkerHandler] writing one JSON line per block, with its clock,
+// cursor and output decoded using the Protobuf descriptors embedded in the package, into rolling
+// files of a local directory.
+//
+// Blocks are written to an uncommitted `<first block>.jsonl.partial` file. Once rolled, a file
+// is committed, renamed to `<first block>-<last block>.jsonl`, as soon as all its blocks are
+// final, the cursor of its last block being then saved to `cursor.json` in the same directory.
+// Undo signals truncate the uncommitted files back to the last valid block, committed files are
+// never modified. Restart from the cursor returned by [JSONLHandler.LoadCursor].
+type JSONLHandler struct {
+	sinker        *Sinker
+	directory     string
+	blocksPerFile uint64
+	maxFileSize   int64
+	cursorStore   *FileCursorStore
+
+	// uncommitted files, oldest first, only the last one can be open for writing
+	uncommitted   []*jsonlFile
+	lastCommitted uint64
+	hasCommitted  bool
+	finalBlock    uint64
+}
+
+type jsonlFile struct {
+	path   string
+	file   *os.File
+	size   int64
+	blocks []jsonlBlock
+}
+
+type jsonlBlock struct {
+	num    uint64
+	end    int64
+	cursor *Cursor
+}
+
+type jsonlLine struct {
+	Clock  jsonlClock      `json:"clock"`
+	Cursor string          `json:"cursor"`
+	Output json.RawMessage `json:"output"`
+}
+
+type jsonlClock struct {
+	Number    uint64    `json:"number"`
+	ID        string    `json:"id"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// NewJSONLHandler returns a [JSONLHandler] writing into `directory`, created if missing, the
+// output of the module streamed by `sinker` being decoded with [Sinker.DecodeOutputJSON].
+func NewJSONLHandler(sinker *Sinker, directory string, opts ...JSONLOption) (*JSONLHandler, error) {
+	if err := os.MkdirAll(directory, 0o755); err != nil {
+		return nil, fmt.Errorf("create output directory: %w", err)
+	}
+
+	h := &JSONLHandler{
+		sinker:        sinker,
+		directory:     directory,
+		blocksPerFile: 1000,
+		cursorStore:   NewFileCursorStore(filepath.Join(directory, jsonlCursorFile)),
+	}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h, nil
+}
+
+// LoadCursor returns the cursor to restart from, the one of the last block committed. Files
+// left uncommitted, or committed after the cursor was saved, by a previous run are removed.
+//
+// An error matching [ErrModuleHashChanged] is returned if the files were produced by a module
+// with a different hash than the one streamed by the sinker.
+func (h *JSONLHandler) LoadCursor(ctx context.Context) (*Cursor, error) {
+	cursor, moduleHash, err := h.cursorStore.Load(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("load cursor: %w", err)
+	}
+
+	if moduleHash != "" && moduleHash != h.sinker.OutputModuleHash() {
+		return nil, &ModuleHashChangedError{StoredHash: moduleHash, CurrentHash: h.sinker.OutputModuleHash()}
+	}
+
+	entries, err := os.ReadDir(h.directory)
+	if err != nil {
+		return nil, fmt.Errorf("list output directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+
+		var stale bool
+		switch {
+		case strings.HasSuffix(name, jsonlUncommittedSuffix):
+			stale = true
+		case strings.HasSuffix(name, jsonlCommittedSuffix):
+			var first, last uint64
+			if _, err := fmt.Sscanf(name, "%d-%d"+jsonlCommittedSuffix, &first, &last); err != nil {
+				continue
+			}
+
+			stale = cursor.IsBlank() || first > cursor.Block().Num()
+		}
+
+		if stale {
+			h.sinker.logger.Info("removing file written after the stored cursor", zap.String("file", name), zap.Stringer("cursor", cursor))
+			if err := os.Remove(filepath.Join(h.directory, name)); err != nil {
+				return nil, fmt.Errorf("remove stale file: %w", err)
+			}
+		}
+	}
+
+	if !cursor.IsBlank() {
+		h.lastCommitted, h.hasCommitted = cursor.Block().Num(), true
+	}
+
+	return cursor, nil
+}
+
+func (h *JSONLHandler) HandleBlockScopedData(ctx context.Context, data *pbsubstreamsrpc.BlockScopedData, _ *bool, cursor *Cursor) error {
+	output, err := h.sinker.DecodeOutputJSON(data)
+	if err != nil {
+		return fmt.Errorf("decode output: %w", err)
+	}
+
+	content, err := json.Marshal(jsonlLine{
+		Clock: jsonlClock{
+			Number:    data.Clock.GetNumber(),
+			ID:        data.Clock.GetId(),
+			Timestamp: data.Clock.GetTimestamp().AsTime(),
+		},
+		Cursor: cursor.String(),
+		Output: output,
+	})
+	if err != nil {
+		return fmt.Errorf("encode line: %w", err)
+	}
+
+	current, err := h.currentFile(data.Clock.GetNumber())
+	if err != nil {
+		return err
+	}
+
+	if err := current.append(append(content, '\n'), data.Clock.GetNumber(), cursor); err != nil {
+		return err
+	}
+
+	h.finalBlock = data.GetFinalBlockHeight()
+	return h.commitFinal(ctx, false)
+}
+
+func (h *JSONLHandler) HandleBlockUndoSignal(_ context.Context, undoSignal *pbsubstreamsrpc.BlockUndoSignal, _ *Cursor) error {
+	lastValidBlock := undoSignal.LastValidBlock.GetNumber()
+	if h.hasCommitted && lastValidBlock < h.lastCommitted {
+		return fmt.Errorf("undo to block #%d before last committed block #%d", lastValidBlock, h.lastCommitted)
+	}
+
+	for len(h.uncommitted) > 0 {
+		last := h.uncommitted[len(h.uncommitted)-1]
+		if last.blocks[0].num <= lastValidBlock {
+			return last.truncate(lastValidBlock)
+		}
+
+		if err := last.remove(); err != nil {
+			return err
+		}
+		h.uncommitted = h.uncommitted[:len(h.uncommitted)-1]
+	}
+
+	return nil
+}
+
+// HandleFlush closes the current file and commits the files whose blocks are all final.
+func (h *JSONLHandler) HandleFlush(ctx context.Context, _ *Cursor) error {
+	return h.commitFinal(ctx, true)
+}
+
+// HandleBlockRangeCompletion closes the current file and commits all files, the block range
+// being completed no undo can happen anymore.
+func (h *JSONLHandler) HandleBlockRangeCompletion(ctx context.Context, cursor *Cursor) error {
+	h.finalBlock = max(h.finalBlock, cursor.Block().Num())
+	return h.commitFinal(ctx, true)
+}
+
+// Close closes the current file without committing it, a restart removes it.
+func (h *JSONLHandler) Close() error {
+	if len(h.uncommitted) == 0 {
+		return nil
+	}
+
+	return h.uncommitted[len(h.uncommitted)-1].close()
+}
+
+// currentFile returns the file `blockNum` must be written to, rolling the current one if full.
+func (h *JSONLHandler) currentFile(blockNum uint64) (*jsonlFile, error) {
+	if len(h.uncommitted) > 0 {
+		current := h.uncommitted[len(h.uncommitted)-1]
+		if current.file != nil && !h.isFull(current, blockNum) {
+			return current, nil
+		}
+
+		if err := current.close(); err != nil {
+			return nil, err
+		}
+	}
+
+	path := filepath.Join(h.directory, fmt.Sprintf("%010d%s", blockNum, jsonlUncommittedSuffix))
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("create file: %w", err)
+	}
+
+	current := &jsonlFile{path: path, file: file}
+	h.uncommitted = append(h.uncommitted, current)
+
+	return current, nil
+}
+
+func (h *JSONLHandler) isFull(file *jsonlFile, blockNum uint64) bool {
+	if h.maxFileSize > 0 && file.size >= h.maxFileSize {
+		return true
+	}
+
+	return h.blocksPerFile > 0 && blockNum/h.blocksPerFile != file.blocks[0].num/h.blocksPerFile
+}
+
+// commitFinal commits the rolled files whose blocks are all final, oldest first, the current
+// file is closed beforehand if `closeCurrent` is set.
+func (h *JSONLHandler) commitFinal(ctx context.Context, closeCurrent bool) error {
+	if closeCurrent && len(h.uncommitted) > 0 {
+		if err := h.uncommitted[len(h.uncommitted)-1].close(); err != nil {
+			return err
+		}
+	}
+
+	for len(h.uncommitted) > 0 {
+		oldest := h.uncommitted[0]
+		last := oldest.blocks[len(oldest.blocks)-1]
+		if oldest.file != nil || last.num > h.finalBlock {
+			return nil
+		}
+
+		path := filepath.Join(h.directory, fmt.Sprintf("%010d-%010d%s", oldest.blocks[0].num, last.num, jsonlCommittedSuffix))
+		if err := os.Rename(oldest.path, path); err != nil {
+			return fmt.Errorf("commit file: %w", err)
+		}
+
+		if err := h.cursorStore.Save(ctx, last.cursor, h.sinker.OutputModuleHash()); err != nil {
+			return fmt.Errorf("save cursor: %w", err)
+		}
+
+		h.sinker.logger.Debug("committed file", zap.String("file", filepath.Base(path)), zap.Stringer("cursor", last.cursor))
+		h.uncommitted = h.uncommitted[1:]
+		h.lastCommitted, h.hasCommitted = last.num, true
+	}
+
+	return nil
+}
+
+func (f *jsonlFile) append(line []byte, blockNum uint64, cursor *Cursor) error {
+	if _, err := f.file.Write(line); err != nil {
+		return fmt.Errorf("write line: %w", err)
+	}
+
+	f.size += int64(len(line))
+	f.blocks = append(f.blocks, jsonlBlock{num: blockNum, end: f.size, cursor: cursor})
+	return nil
+}
+
+// truncate drops the blocks after `lastValidBlock` and reopens the file for writing.
+func (f *jsonlFile) truncate(lastValidBlock uint64) error {
+	kept := sort.Search(len(f.blocks), func(i int) bool { return f.blocks[i].num > lastValidBlock })
+	f.blocks = f.blocks[:kept]
+	f.size = f.blocks[kept-1].end
+
+	if f.file == nil {
+		file, err := os.OpenFile(f.path, os.O_WRONLY, 0o644)
+		if err != nil {
+			return fmt.Errorf("reopen file: %w", err)
+		}
+		f.file = file
+	}
+
+	if err := f.file.Truncate(f.size); err != nil {
+		return fmt.Errorf("truncate file: %w", err)
+	}
+
+	if _, err := f.file.Seek(f.size, 0); err != nil {
+		return fmt.Errorf("seek file: %w", err)
+	}
+
+	return nil
+}
+
+func (f *jsonlFile) close() error {
+	if f.file == nil {
+		return nil
+	}
+
+	file := f.file
+	f.file = nil
+
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return fmt.Errorf("sync file: %w", err)
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("close file: %w", err)
+	}
+
+	return nil
+}
+
+func (f *jsonlFile) remove() error {
+	if err := f.close(); err != nil {
+		return err
+	}
+
+	if err := os.Remove(f.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("remove file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,148 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/streamingfast/bstream"
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONLHandler(t *testing.T) {
+	ctx := context.Background()
+	directory := t.TempDir()
+	sinker := newTestSinker(t)
+
+	handler, err := NewJSONLHandler(sinker, directory, WithJSONLBlocksPerFile(10))
+	require.NoError(t, err)
+
+	cursor, err := handler.LoadCursor(ctx)
+	require.NoError(t, err)
+	assert.True(t, cursor.IsBlank())
+
+	// Blocks become final 2 blocks later
+	for num := uint64(1); num <= 12; num++ {
+		testJSONLHandleBlock(t, handler, num, num-min(num, 2))
+	}
+
+	assert.Equal(t, []string{"0000000001-0000000009.jsonl", "0000000010.jsonl.partial", "cursor.json"}, testDirectoryFiles(t, directory))
+	assert.Equal(t, []uint64{1, 2, 3, 4, 5, 6, 7, 8, 9}, testJSONLFileBlocks(t, filepath.Join(directory, "0000000001-0000000009.jsonl")))
+
+	stored, hash, err := NewFileCursorStore(filepath.Join(directory, "cursor.json")).Load(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(9), stored.Block().Num())
+	assert.Equal(t, testKVOutModuleHash, hash)
+
+	require.NoError(t, handler.HandleBlockUndoSignal(ctx, &pbsubstreamsrpc.BlockUndoSignal{LastValidBlock: &pbsubstreams.BlockRef{Id: "10a", Number: 10}}, nil))
+	assert.Equal(t, []uint64{10}, testJSONLFileBlocks(t, filepath.Join(directory, "0000000010.jsonl.partial")))
+
+	testJSONLHandleBlock(t, handler, 11, 9)
+	require.NoError(t, handler.HandleBlockRangeCompletion(ctx, testCursor(11, bstream.StepNew)))
+
+	assert.Equal(t, []string{"0000000001-0000000009.jsonl", "0000000010-0000000011.jsonl", "cursor.json"}, testDirectoryFiles(t, directory))
+	assert.Equal(t, []uint64{10, 11}, testJSONLFileBlocks(t, filepath.Join(directory, "0000000010-0000000011.jsonl")))
+
+	content, err := os.ReadFile(filepath.Join(directory, "0000000010-0000000011.jsonl"))
+	require.NoError(t, err)
+
+	var line map[string]any
+	require.NoError(t, json.Unmarshal([]byte(strings.Split(string(content), "\n")[1]), &line))
+	assert.Equal(t, map[string]any{"number": float64(11), "id": "11a", "timestamp": "1970-01-01T00:00:00Z"}, line["clock"])
+	assert.Equal(t, map[string]any{"id": "11a", "number": "11"}, line["output"])
+	assert.NotEmpty(t, line["cursor"])
+}
+
+func TestJSONLHandler_UndoAcrossFiles(t *testing.T) {
+	ctx := context.Background()
+	directory := t.TempDir()
+
+	handler, err := NewJSONLHandler(newTestSinker(t), directory, WithJSONLBlocksPerFile(0), WithJSONLMaxFileSize(1))
+	require.NoError(t, err)
+
+	// Every block is rolled to its own file, none is final
+	for num := uint64(1); num <= 4; num++ {
+		testJSONLHandleBlock(t, handler, num, 0)
+	}
+	assert.Len(t, testDirectoryFiles(t, directory), 4)
+
+	require.NoError(t, handler.HandleBlockUndoSignal(ctx, &pbsubstreamsrpc.BlockUndoSignal{LastValidBlock: &pbsubstreams.BlockRef{Id: "2a", Number: 2}}, nil))
+	assert.Equal(t, []string{"0000000001.jsonl.partial", "0000000002.jsonl.partial"}, testDirectoryFiles(t, directory))
+
+	testJSONLHandleBlock(t, handler, 3, 3)
+	assert.Equal(t, []string{"0000000001-0000000001.jsonl", "0000000002-0000000002.jsonl", "0000000003.jsonl.partial", "cursor.json"}, testDirectoryFiles(t, directory))
+
+	err = handler.HandleBlockUndoSignal(ctx, &pbsubstreamsrpc.BlockUndoSignal{LastValidBlock: &pbsubstreams.BlockRef{Id: "1a", Number: 1}}, nil)
+	assert.EqualError(t, err, "undo to block #1 before last committed block #2")
+}
+
+func TestJSONLHandler_LoadCursor(t *testing.T) {
+	ctx := context.Background()
+	directory := t.TempDir()
+
+	for _, name := range []string{"0000000001-0000000009.jsonl", "0000000010-0000000019.jsonl", "0000000020.jsonl.partial", "notes.txt"} {
+		require.NoError(t, os.WriteFile(filepath.Join(directory, name), nil, 0o644))
+	}
+
+	store := NewFileCursorStore(filepath.Join(directory, "cursor.json"))
+	require.NoError(t, store.Save(ctx, testCursor(9, bstream.StepNew), testKVOutModuleHash))
+
+	handler, err := NewJSONLHandler(newTestSinker(t), directory)
+	require.NoError(t, err)
+
+	cursor, err := handler.LoadCursor(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(9), cursor.Block().Num())
+	assert.Equal(t, []string{"0000000001-0000000009.jsonl", "cursor.json", "notes.txt"}, testDirectoryFiles(t, directory))
+
+	require.NoError(t, store.Save(ctx, testCursor(9, bstream.StepNew), "abcd"))
+	_, err = handler.LoadCursor(ctx)
+	assert.ErrorIs(t, err, ErrModuleHashChanged)
+}
+
+func testJSONLHandleBlock(t *testing.T, handler *JSONLHandler, num uint64, finalBlock uint64) {
+	t.Helper()
+
+	data := testCursorDataResponse(num).GetBlockScopedData()
+	data.Output.MapOutput = testClockOutput(t, &pbsubstreams.Clock{Id: data.Clock.Id, Number: num})
+	data.FinalBlockHeight = finalBlock
+
+	cursor, err := NewCursor(data.Cursor)
+	require.NoError(t, err)
+
+	require.NoError(t, handler.HandleBlockScopedData(context.Background(), data, nil, cursor))
+}
+
+func testJSONLFileBlocks(t *testing.T, path string) (blocks []uint64) {
+	t.Helper()
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	for _, line := range strings.Split(strings.TrimSuffix(string(content), "\n"), "\n") {
+		var decoded jsonlLine
+		require.NoError(t, json.Unmarshal([]byte(line), &decoded))
+		blocks = append(blocks, decoded.Clock.Number)
+	}
+
+	return blocks
+}
+
+func testDirectoryFiles(t *testing.T, directory string) (names []string) {
+	t.Helper()
+
+	entries, err := os.ReadDir(directory)
+	require.NoError(t, err)
+
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+
+	return names
+}
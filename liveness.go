@@ -3,6 +3,7 @@ package sink
 import (
 	"time"
 
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
 	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
 )
 
@@ -10,6 +11,28 @@ type LivenessChecker interface {
 	IsLive(block *pbsubstreams.Clock) bool
 }
 
+// BlockScopedDataLivenessChecker is a [LivenessChecker] that needs more than the block's clock
+// to determine liveness, when the configured [LivenessChecker] implements it, the [Sinker]
+// calls [BlockScopedDataLivenessChecker.IsLiveBlockScopedData] instead of [LivenessChecker.IsLive].
+type BlockScopedDataLivenessChecker interface {
+	LivenessChecker
+
+	IsLiveBlockScopedData(data *pbsubstreamsrpc.BlockScopedData) bool
+}
+
+// isLiveBlockScopedData checks the liveness of `data` with `checker`, using
+// [BlockScopedDataLivenessChecker] when implemented.
+func isLiveBlockScopedData(checker LivenessChecker, data *pbsubstreamsrpc.BlockScopedData) bool {
+	if dataChecker, ok := checker.(BlockScopedDataLivenessChecker); ok {
+		return dataChecker.IsLiveBlockScopedData(data)
+	}
+
+	return checker.IsLive(data.Clock)
+}
+
+// DeltaLivenessChecker considers the chain live once a block's time is within `delta` of the
+// current time. Once live, it stays live forever, even after a long stall, use
+// [HysteresisLivenessChecker] to be notified when the chain is not live anymore.
 type DeltaLivenessChecker struct {
 	delta   time.Duration
 	nowFunc func() time.Time
@@ -47,3 +70,138 @@ func (t *DeltaLivenessChecker) IsLive(clock *pbsubstreams.Clock) bool {
 
 	return t.isLive
 }
+
+// HysteresisLivenessChecker is a non-latching [DeltaLivenessChecker], it becomes live once a
+// block's time is within `liveDelta` of the current time and becomes not live again once a
+// block's time is more than `notLiveDelta` behind the current time. Using a `notLiveDelta`
+// larger than `liveDelta` avoids flapping between the two states when blocks are produced
+// irregularly around the threshold.
+type HysteresisLivenessChecker struct {
+	liveDelta    time.Duration
+	notLiveDelta time.Duration
+	nowFunc      func() time.Time
+
+	isLive bool
+}
+
+// NewHysteresisLivenessChecker returns a [HysteresisLivenessChecker], `notLiveDelta` is raised
+// to `liveDelta` if it's lower.
+func NewHysteresisLivenessChecker(liveDelta time.Duration, notLiveDelta time.Duration) *HysteresisLivenessChecker {
+	if notLiveDelta < liveDelta {
+		notLiveDelta = liveDelta
+	}
+
+	return &HysteresisLivenessChecker{
+		liveDelta:    liveDelta,
+		notLiveDelta: notLiveDelta,
+		nowFunc:      time.Now,
+	}
+}
+
+func (t *HysteresisLivenessChecker) IsLive(clock *pbsubstreams.Clock) bool {
+	if clock == nil || clock.GetTimestamp() == nil {
+		return t.isLive
+	}
+
+	drift := t.nowFunc().Sub(clock.GetTimestamp().AsTime())
+	if t.isLive {
+		t.isLive = drift <= t.notLiveDelta
+	} else {
+		t.isLive = drift <= t.liveDelta
+	}
+
+	return t.isLive
+}
+
+// FinalBlockDistanceLivenessChecker considers a block live when it's at most `maxDistance`
+// blocks behind the chain's final block height reported by the Substreams endpoint with each
+// block, it's not latching. It does not depend on the local clock nor on the chain's block
+// time, which makes it suited for chains with irregular block production.
+//
+// When only the clock is known, see [LivenessChecker.IsLive], the last known state is returned.
+type FinalBlockDistanceLivenessChecker struct {
+	maxDistance uint64
+
+	isLive bool
+}
+
+func NewFinalBlockDistanceLivenessChecker(maxDistance uint64) *FinalBlockDistanceLivenessChecker {
+	return &FinalBlockDistanceLivenessChecker{maxDistance: maxDistance}
+}
+
+func (t *FinalBlockDistanceLivenessChecker) IsLive(_ *pbsubstreams.Clock) bool {
+	return t.isLive
+}
+
+func (t *FinalBlockDistanceLivenessChecker) IsLiveBlockScopedData(data *pbsubstreamsrpc.BlockScopedData) bool {
+	if data.Clock == nil || data.FinalBlockHeight == 0 {
+		return t.isLive
+	}
+
+	t.isLive = data.Clock.Number+t.maxDistance >= data.FinalBlockHeight
+
+	return t.isLive
+}
+
+// CompositeLivenessChecker combines multiple [LivenessChecker], it's live when all of them are
+// live, see [NewAllLivenessChecker], or when any of them is live, see [NewAnyLivenessChecker].
+// Every checker is called for each block so stateful checkers keep their state up to date.
+type CompositeLivenessChecker struct {
+	checkers   []LivenessChecker
+	requireAll bool
+}
+
+// NewAllLivenessChecker returns a [CompositeLivenessChecker] that is live when all `checkers`
+// are live.
+func NewAllLivenessChecker(checkers ...LivenessChecker) *CompositeLivenessChecker {
+	return &CompositeLivenessChecker{checkers: checkers, requireAll: true}
+}
+
+// NewAnyLivenessChecker returns a [CompositeLivenessChecker] that is live when any of
+// `checkers` is live.
+func NewAnyLivenessChecker(checkers ...LivenessChecker) *CompositeLivenessChecker {
+	return &CompositeLivenessChecker{checkers: checkers, requireAll: false}
+}
+
+func (t *CompositeLivenessChecker) IsLive(clock *pbsubstreams.Clock) bool {
+	return t.combine(func(checker LivenessChecker) bool { return checker.IsLive(clock) })
+}
+
+func (t *CompositeLivenessChecker) IsLiveBlockScopedData(data *pbsubstreamsrpc.BlockScopedData) bool {
+	return t.combine(func(checker LivenessChecker) bool { return isLiveBlockScopedData(checker, data) })
+}
+
+func (t *CompositeLivenessChecker) combine(isLive func(checker LivenessChecker) bool) bool {
+	if len(t.checkers) == 0 {
+		return false
+	}
+
+	result := t.requireAll
+	for _, checker := range t.checkers {
+		if t.requireAll {
+			result = isLive(checker) && result
+		} else {
+			result = isLive(checker) || result
+		}
+	}
+
+	return result
+}
+
+// livenessTracker checks the liveness of each block and reports liveness transitions, the
+// initial state is not live so the first live block is a transition.
+type livenessTracker struct {
+	checker LivenessChecker
+
+	isLive bool
+}
+
+// Check returns the liveness of `data` and whether it differs from the liveness of the
+// previous block.
+func (t *livenessTracker) Check(data *pbsubstreamsrpc.BlockScopedData) (isLive bool, transitioned bool) {
+	isLive = isLiveBlockScopedData(t.checker, data)
+	transitioned = isLive != t.isLive
+	t.isLive = isLive
+
+	return isLive, transitioned
+}
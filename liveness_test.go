@@ -1,10 +1,18 @@
 package sink
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
 	"testing"
 	"time"
 
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
 	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/anypb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
@@ -43,6 +51,187 @@ func TestLivenessChecker_IsLive(t *testing.T) {
 
 }
 
+func TestHysteresisLivenessChecker_IsLive(t *testing.T) {
+	tnow, _ := time.Parse(time.RFC3339, "2023-01-01T00:00:00Z")
+
+	livenessChecker := NewHysteresisLivenessChecker(3*time.Second, 10*time.Second)
+	livenessChecker.nowFunc = func() time.Time { return tnow }
+
+	tests := []struct {
+		clock          *pbsubstreams.Clock
+		expectedResult bool
+	}{
+		{testClock("1a", 1, tnow.Add(-5*time.Second)), false},
+		{testClock("2a", 2, tnow.Add(-3*time.Second)), true}, // live threshold reached
+		{testClock("3a", 3, tnow.Add(-8*time.Second)), true}, // within not live threshold
+		{nil, true},
+		{testClock("4a", 4, tnow.Add(-11*time.Second)), false}, // not live threshold exceeded
+		{testClock("5a", 5, tnow.Add(-8*time.Second)), false},
+		{testClock("6a", 6, tnow.Add(-1*time.Second)), true},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expectedResult, livenessChecker.IsLive(tt.clock), "block %s", tt.clock.GetId())
+	}
+}
+
+func TestFinalBlockDistanceLivenessChecker(t *testing.T) {
+	livenessChecker := NewFinalBlockDistanceLivenessChecker(10)
+
+	tests := []struct {
+		data           *pbsubstreamsrpc.BlockScopedData
+		expectedResult bool
+	}{
+		{testBlockScopedData(100, 200), false},
+		{testBlockScopedData(190, 200), true},
+		{testBlockScopedData(205, 0), true}, // unknown final block height keeps last state
+		{testBlockScopedData(210, 200), true},
+		{testBlockScopedData(150, 200), false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expectedResult, isLiveBlockScopedData(livenessChecker, tt.data), "block %d", tt.data.Clock.Number)
+	}
+
+	assert.False(t, livenessChecker.IsLive(nil), "returns last known state")
+}
+
+func TestCompositeLivenessChecker(t *testing.T) {
+	live := fixedLivenessChecker(true)
+	notLive := fixedLivenessChecker(false)
+	data := testBlockScopedData(190, 200)
+
+	assert.True(t, NewAllLivenessChecker(live, live).IsLive(nil))
+	assert.False(t, NewAllLivenessChecker(live, notLive).IsLive(nil))
+	assert.True(t, NewAnyLivenessChecker(notLive, live).IsLive(nil))
+	assert.False(t, NewAnyLivenessChecker(notLive, notLive).IsLive(nil))
+	assert.False(t, NewAnyLivenessChecker().IsLive(nil))
+
+	assert.True(t, isLiveBlockScopedData(NewAllLivenessChecker(live, NewFinalBlockDistanceLivenessChecker(10)), data))
+	assert.False(t, isLiveBlockScopedData(NewAllLivenessChecker(live, NewFinalBlockDistanceLivenessChecker(5)), data))
+
+	// Every checker is called even when the result is already known
+	distance := NewFinalBlockDistanceLivenessChecker(10)
+	assert.True(t, isLiveBlockScopedData(NewAnyLivenessChecker(live, distance), data))
+	assert.True(t, distance.IsLive(nil))
+}
+
+func Test_livenessTracker(t *testing.T) {
+	type transition struct {
+		isLive bool
+		block  uint64
+	}
+
+	var transitions []transition
+	tracker := &livenessTracker{checker: NewFinalBlockDistanceLivenessChecker(0)}
+	check := func(num uint64, finalBlockHeight uint64) bool {
+		isLive, transitioned := tracker.Check(testBlockScopedData(num, finalBlockHeight))
+		if transitioned {
+			transitions = append(transitions, transition{isLive, num})
+		}
+
+		return isLive
+	}
+
+	assert.False(t, check(1, 10))
+	assert.True(t, check(10, 10))
+	assert.True(t, check(11, 10))
+	assert.False(t, check(12, 20))
+	assert.False(t, check(13, 20))
+
+	assert.Equal(t, []transition{{true, 10}, {false, 12}}, transitions)
+}
+
+func TestSinker_LivenessHandler(t *testing.T) {
+	var callbacks, calls []string
+	handler := &testLivenessHandler{
+		SinkerHandler: NewSinkerHandlers(
+			func(ctx context.Context, data *pbsubstreamsrpc.BlockScopedData, isLive *bool, cursor *Cursor) error {
+				calls = append(calls, fmt.Sprintf("data %d live=%t", data.Clock.Number, *isLive))
+				return nil
+			},
+			func(ctx context.Context, undoSignal *pbsubstreamsrpc.BlockUndoSignal, cursor *Cursor) error {
+				return nil
+			},
+		),
+		calls: &calls,
+	}
+
+	sinker := newTestSinker(t,
+		WithLivenessChecker(NewFinalBlockDistanceLivenessChecker(0)),
+		WithLivenessTransitionCallback(func(isLive bool, block *pbsubstreams.Clock) {
+			callbacks = append(callbacks, fmt.Sprintf("%d live=%t", block.Number, isLive))
+		}),
+	)
+
+	stream := &fakeBlocksClient{eof: true}
+	for _, data := range []*pbsubstreamsrpc.BlockScopedData{
+		testBlockScopedData(1, 3), testBlockScopedData(2, 3), testBlockScopedData(3, 3), testBlockScopedData(4, 3), testBlockScopedData(5, 10),
+	} {
+		data.Output = &pbsubstreamsrpc.MapModuleOutput{Name: "kv_out", MapOutput: &anypb.Any{}}
+		stream.messages = append(stream.messages, &pbsubstreamsrpc.Response{Message: &pbsubstreamsrpc.Response_BlockScopedData{BlockScopedData: data}})
+	}
+
+	_, _, err := sinker.doRequest(context.Background(), nil, &pbsubstreamsrpc.Request{}, &fakeStreamClient{stream}, nil, handler)
+	require.ErrorIs(t, err, io.EOF)
+
+	assert.Equal(t, []string{
+		"data 1 live=false",
+		"data 2 live=false",
+		"on live 3",
+		"data 3 live=true",
+		"data 4 live=true",
+		"on not live 5",
+		"data 5 live=false",
+	}, calls)
+	assert.Equal(t, []string{"3 live=true", "5 live=false"}, callbacks)
+
+	handler.err = errors.New("database unavailable")
+	stream = &fakeBlocksClient{eof: true, messages: []*pbsubstreamsrpc.Response{{Message: &pbsubstreamsrpc.Response_BlockScopedData{BlockScopedData: &pbsubstreamsrpc.BlockScopedData{
+		Output:           &pbsubstreamsrpc.MapModuleOutput{Name: "kv_out", MapOutput: &anypb.Any{}},
+		Clock:            &pbsubstreams.Clock{Id: "10a", Number: 10},
+		FinalBlockHeight: 10,
+	}}}}}
+
+	_, _, err = sinker.doRequest(context.Background(), nil, &pbsubstreamsrpc.Request{}, &fakeStreamClient{stream}, nil, handler)
+	require.EqualError(t, err, "handle LivenessTransition at block #10 (10a): database unavailable")
+
+	var handlerErr *HandlerError
+	require.ErrorAs(t, err, &handlerErr)
+	assert.Equal(t, MessageTypeLivenessTransition, handlerErr.MessageType)
+	assert.Equal(t, uint64(10), handlerErr.Block.Num())
+}
+
+type testLivenessHandler struct {
+	SinkerHandler
+
+	calls *[]string
+	err   error
+}
+
+func (h *testLivenessHandler) OnLive(ctx context.Context, block *pbsubstreams.Clock) error {
+	*h.calls = append(*h.calls, fmt.Sprintf("on live %d", block.Number))
+	return h.err
+}
+
+func (h *testLivenessHandler) OnNotLive(ctx context.Context, block *pbsubstreams.Clock) error {
+	*h.calls = append(*h.calls, fmt.Sprintf("on not live %d", block.Number))
+	return h.err
+}
+
+type fixedLivenessChecker bool
+
+func (c fixedLivenessChecker) IsLive(_ *pbsubstreams.Clock) bool {
+	return bool(c)
+}
+
+func testBlockScopedData(num uint64, finalBlockHeight uint64) *pbsubstreamsrpc.BlockScopedData {
+	return &pbsubstreamsrpc.BlockScopedData{
+		Clock:            &pbsubstreams.Clock{Id: fmt.Sprintf("%da", num), Number: num},
+		FinalBlockHeight: finalBlockHeight,
+	}
+}
+
 func testClock(id string, num uint64, time time.Time) *pbsubstreams.Clock {
 	return &pbsubstreams.Clock{
 		Id:        id,
@@ -33,6 +33,12 @@ func ReadManifestAndModule(
 	outputModuleHash manifest.ModuleHash,
 	err error,
 ) {
+	defer func() {
+		if err != nil {
+			err = &ManifestError{ManifestPath: manifestPath, OutputModule: outputModuleName, Err: err}
+		}
+	}()
+
 	zlog.Info("reading substreams manifest", zap.String("manifest_path", manifestPath))
 
 	var opts []manifest.Option
@@ -95,7 +101,8 @@ func ReadManifestAndModule(
 	return pkg, module, outputModuleHash, nil
 }
 
-// ReadManifestAndModuleAndBlockRange acts exactly like ReadManifestAndModule but also reads the block range.
+// ReadManifestAndModuleAndBlockRange acts exactly like ReadManifestAndModule but also reads the block range,
+// which must be a single absolute segment, see [ReadBlockRange].
 func ReadManifestAndModuleAndBlockRange(
 	manifestPath string,
 	network string,
@@ -127,6 +134,39 @@ func ReadManifestAndModuleAndBlockRange(
 	return
 }
 
+// ReadManifestAndModuleAndBlockRanges acts exactly like ReadManifestAndModule but also reads the block range,
+// which can have multiple segments or be relative to the chain's head block, see [ReadBlockRanges].
+func ReadManifestAndModuleAndBlockRanges(
+	manifestPath string,
+	network string,
+	params []string,
+	outputModuleName string,
+	expectedOutputModuleType string,
+	skipPackageValidation bool,
+	blockRange string,
+	zlog *zap.Logger,
+) (
+	pkg *pbsubstreams.Package,
+	module *pbsubstreams.Module,
+	outputModuleHash manifest.ModuleHash,
+	resolvedBlockRanges BlockRanges,
+	err error,
+) {
+	pkg, module, outputModuleHash, err = ReadManifestAndModule(manifestPath, network, params, outputModuleName, expectedOutputModuleType, skipPackageValidation, zlog)
+	if err != nil {
+		err = fmt.Errorf("read manifest and module: %w", err)
+		return
+	}
+
+	resolvedBlockRanges, err = ReadBlockRanges(module, blockRange)
+	if err != nil {
+		err = fmt.Errorf("resolve block range: %w", err)
+		return
+	}
+
+	return
+}
+
 // sanitizeModuleTypes has the same behavior as sanitizeModuleType but explodes
 // the inpput string on comma and returns a slice of unprefixed and prefixed
 // types for each of the input types.
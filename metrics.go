@@ -11,7 +11,8 @@ var metrics = dmetrics.NewSet()
 var HeadBlockNumber = metrics.NewHeadBlockNumber("substreams_sink")
 var HeadBlockTimeDrift = metrics.NewHeadTimeDrift("substreams_sink")
 
-var MessageSizeBytes = metrics.NewCounter("substreams_sink_message_size_bytes", "The number of total bytes of message received from the Substreams backend")
+var MessageSizeBytes = metrics.NewCounter("substreams_sink_message_size_bytes", "The number of total bytes of message received from the Substreams backend, once decoded")
+var MessageWireSizeBytes = metrics.NewCounter("substreams_sink_message_wire_size_bytes", "The number of total bytes of message received from the Substreams backend as seen on the wire, before decompression, only recorded when the stream is compressed")
 
 var SubstreamsErrorCount = metrics.NewCounter("substreams_sink_error", "The error count we encountered when interacting with Substreams for which we had to restart the connection loop")
 var DataMessageCount = metrics.NewCounter("substreams_sink_data_message", "The number of data message received")
@@ -25,3 +26,9 @@ var UndoMessageCount = metrics.NewCounter("substreams_sink_undo_message", "The n
 var UnknownMessageCount = metrics.NewCounter("substreams_sink_unknown_message", "The number of unknown message received")
 
 var BackprocessingCompletion = metrics.NewGauge("substreams_sink_backprocessing_completion", "Determines if backprocessing is completed, which is if we receive a first data message")
+
+var Paused = metrics.NewGauge("substreams_sink_paused", "Set to 1 while the sinker is paused and does not dispatch messages to the handler, 0 otherwise")
+
+var ActiveEndpoint = metrics.NewGaugeVec("substreams_sink_active_endpoint", []string{"endpoint"}, "Set to 1 for the Substreams endpoint currently streamed from and 0 for the other configured endpoints")
+var EndpointFailoverCount = metrics.NewCounter("substreams_sink_endpoint_failover", "The number of times the sinker switched to the next Substreams endpoint after repeated failures")
+var StallWatchdogReconnectCount = metrics.NewCounter("substreams_sink_stall_watchdog_reconnect", "The number of times the stall watchdog canceled the Substreams stream and reconnected because no message was received within the configured timeout")
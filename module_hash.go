@@ -0,0 +1,133 @@
+package sink
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+// ErrModuleHashChanged is matched, through [errors.Is], by the [ModuleHashChangedError] the
+// [Sinker] terminates with under [ModuleHashChangePolicyFail].
+var ErrModuleHashChanged = errors.New("output module hash changed")
+
+// ModuleHashChange describes an output module hash change detected at startup, see
+// [SinkerModuleHashChangeHandler].
+type ModuleHashChange struct {
+	// StoredHash is the hash of the output module that produced the stored cursor.
+	StoredHash string
+	// CurrentHash is the hash of the output module streamed now.
+	CurrentHash string
+	// Policy is the policy applied, see [WithModuleHashChangePolicy].
+	Policy ModuleHashChangePolicy
+	// Cursor is the stored cursor, under [ModuleHashChangePolicyReset] it's discarded and the
+	// [Sinker] restarts from the output module's initial block.
+	Cursor *Cursor
+}
+
+// ModuleHashChangedError is the error the [Sinker] terminates with when the output module hash
+// changed under [ModuleHashChangePolicyFail].
+type ModuleHashChangedError struct {
+	StoredHash  string
+	CurrentHash string
+}
+
+func (e *ModuleHashChangedError) Error() string {
+	return fmt.Sprintf("output module hash changed from %s to %s since the stored cursor was produced, the data already sunk was produced by a different module", e.StoredHash, e.CurrentHash)
+}
+
+func (e *ModuleHashChangedError) Is(target error) bool { return target == ErrModuleHashChanged }
+
+// resolveStartCursor returns the cursor to start from, loaded from the [CursorStore] if `cursor`
+// is blank, after having compared the stored output module hash with the current one.
+func (s *Sinker) resolveStartCursor(ctx context.Context, cursor *Cursor, handler SinkerHandler) (*Cursor, error) {
+	storedHash := s.storedModuleHash
+	if s.cursorStore != nil {
+		storedCursor, hash, err := s.cursorStore.Load(ctx)
+		if err != nil {
+			return cursor, fmt.Errorf("load cursor from store: %w", err)
+		}
+
+		if cursor.IsBlank() {
+			cursor = storedCursor
+		}
+
+		if hash != "" {
+			storedHash = hash
+		}
+	}
+
+	if storedHash == "" || storedHash == s.outputModuleHash {
+		return cursor, nil
+	}
+
+	change := &ModuleHashChange{StoredHash: storedHash, CurrentHash: s.outputModuleHash, Policy: s.moduleHashChangePolicy, Cursor: cursor}
+	fields := []zap.Field{
+		zap.String("stored_hash", change.StoredHash),
+		zap.String("current_hash", change.CurrentHash),
+		zap.Stringer("policy", change.Policy),
+		zap.Stringer("cursor", cursor),
+	}
+
+	switch s.moduleHashChangePolicy {
+	case ModuleHashChangePolicyWarn:
+		s.logger.Warn("output module hash changed since the stored cursor was produced, continuing from it", fields...)
+
+	case ModuleHashChangePolicyReset:
+		s.logger.Warn("output module hash changed since the stored cursor was produced, restarting from module's initial block", append(fields, zap.Uint64("initial_block", s.outputModule.InitialBlock))...)
+
+	default:
+		s.logger.Error("output module hash changed since the stored cursor was produced, refusing to continue", fields...)
+	}
+
+	if v, ok := handler.(SinkerModuleHashChangeHandler); ok {
+		if err := v.HandleModuleHashChange(ctx, change); err != nil {
+			return cursor, newHandlerError(MessageTypeModuleHashChange, cursor, err)
+		}
+	}
+
+	switch s.moduleHashChangePolicy {
+	case ModuleHashChangePolicyWarn:
+		return cursor, nil
+	case ModuleHashChangePolicyReset:
+		if err := s.resetBlockRangesToInitialBlock(); err != nil {
+			return cursor, fmt.Errorf("reset to module's initial block: %w", err)
+		}
+
+		return NewBlankCursor(), nil
+	default:
+		return cursor, &ModuleHashChangedError{StoredHash: change.StoredHash, CurrentHash: change.CurrentHash}
+	}
+}
+
+// resetBlockRangesToInitialBlock makes the first requested block range segment start at the
+// output module's initial block, keeping its end block and the following segments.
+func (s *Sinker) resetBlockRangesToInitialBlock() error {
+	blockRanges := append(BlockRanges(nil), s.requestedBlockRanges...)
+	blockRanges[0] = NewBlockRange(int64(s.outputModule.InitialBlock), blockRanges[0].EndBlock())
+	if err := blockRanges.Validate(); err != nil {
+		return err
+	}
+
+	// Reassigned under the lock since [Sinker.RequestedBlockRanges] reads it concurrently
+	s.state.update(func(state *runtimeState) {
+		s.requestedBlockRanges = blockRanges
+	})
+	s.activateBlockRange(blockRanges[0])
+
+	return nil
+}
+
+// saveCursor saves `cursor` to the [CursorStore], if any, nothing is saved in dry-run mode.
+func (s *Sinker) saveCursor(ctx context.Context, cursor *Cursor) error {
+	if s.cursorStore == nil || s.dryRunOutput != nil {
+		return nil
+	}
+
+	if err := s.cursorStore.Save(ctx, cursor, s.outputModuleHash); err != nil {
+		return fmt.Errorf("save cursor to store: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,185 @@
+package sink
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/streamingfast/bstream"
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testKVOutModuleHash = "f0b74c6dc57fa840bf1e7ff526431f9f1b5240d0"
+
+func TestSinker_resolveStartCursor(t *testing.T) {
+	storedCursor := MustNewCursor(testCursorDataResponse(5).GetBlockScopedData().Cursor)
+
+	tests := []struct {
+		name             string
+		storedHash       string
+		policy           ModuleHashChangePolicy
+		handlerErr       error
+		expectedBlock    uint64
+		expectedNotified bool
+		expectedErrIs    error
+	}{
+		{"no stored hash", "", ModuleHashChangePolicyFail, nil, 5, false, nil},
+		{"same hash", testKVOutModuleHash, ModuleHashChangePolicyFail, nil, 5, false, nil},
+		{"changed, fail", "0123", ModuleHashChangePolicyFail, nil, 5, true, ErrModuleHashChanged},
+		{"changed, warn", "0123", ModuleHashChangePolicyWarn, nil, 5, true, nil},
+		{"changed, reset", "0123", ModuleHashChangePolicyReset, nil, 0, true, nil},
+		{"changed, handler error", "0123", ModuleHashChangePolicyWarn, errors.New("refused"), 5, true, ErrHandlerFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := &testModuleHashChangeHandler{SinkerHandler: NewSinkerHandlers(nil, nil), err: tt.handlerErr}
+			sinker := newTestSinker(t, WithStoredModuleHash(tt.storedHash), WithModuleHashChangePolicy(tt.policy), WithRequestedBlockRanges(NewBlockRange(1_000_000, nil)))
+
+			cursor, err := sinker.resolveStartCursor(context.Background(), storedCursor, handler)
+			if tt.expectedErrIs == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, tt.expectedErrIs)
+			}
+
+			if tt.expectedBlock == 0 {
+				assert.True(t, cursor.IsBlank())
+				assert.Equal(t, bstream.NewOpenRange(sinker.OutputModule().InitialBlock), sinker.BlockRange(), "restarts from module's initial block")
+			} else {
+				assert.Equal(t, tt.expectedBlock, cursor.Block().Num())
+				assert.Equal(t, bstream.NewOpenRange(1_000_000), sinker.BlockRange())
+			}
+
+			if tt.expectedNotified {
+				require.NotNil(t, handler.change)
+				assert.Equal(t, &ModuleHashChange{StoredHash: "0123", CurrentHash: testKVOutModuleHash, Policy: tt.policy, Cursor: storedCursor}, handler.change)
+			} else {
+				assert.Nil(t, handler.change)
+			}
+		})
+	}
+}
+
+func TestSinker_CursorStore(t *testing.T) {
+	endpoint := newTestStreamServer(t, func(req *pbsubstreamsrpc.Request, stream pbsubstreamsrpc.Stream_BlocksServer) error {
+		start, err := NewCursor(req.StartCursor)
+		if err != nil {
+			return err
+		}
+
+		from := uint64(1)
+		if !start.IsBlank() {
+			from = start.Block().Num() + 1
+		}
+
+		for num := from; num < from+2; num++ {
+			if err := stream.Send(testCursorDataResponse(num)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	store := NewFileCursorStore(filepath.Join(t.TempDir(), "cursor.json"))
+	handler := NewSinkerHandlers(
+		func(ctx context.Context, data *pbsubstreamsrpc.BlockScopedData, isLive *bool, cursor *Cursor) error {
+			return nil
+		},
+		func(ctx context.Context, undoSignal *pbsubstreamsrpc.BlockUndoSignal, cursor *Cursor) error {
+			return nil
+		},
+	)
+
+	result := newTestPlaintextSinker(t, endpoint, WithCursorStore(store)).RunWithResult(context.Background(), nil, handler)
+	require.Equal(t, TerminationReasonCompleted, result.Reason)
+
+	cursor, hash, err := store.Load(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), cursor.Block().Num())
+	assert.Equal(t, testKVOutModuleHash, hash)
+
+	// Restarts from the stored cursor
+	result = newTestPlaintextSinker(t, endpoint, WithCursorStore(store)).RunWithResult(context.Background(), nil, handler)
+	require.Equal(t, TerminationReasonCompleted, result.Reason)
+	assert.Equal(t, uint64(4), result.LastCursor.Block().Num())
+
+	require.NoError(t, store.Save(context.Background(), cursor, "0123"))
+	result = newTestPlaintextSinker(t, endpoint, WithCursorStore(store)).RunWithResult(context.Background(), nil, handler)
+	assert.Equal(t, TerminationReasonFailed, result.Reason)
+	assert.ErrorIs(t, result.Err, ErrModuleHashChanged)
+	assert.Equal(t, uint64(2), result.LastCursor.Block().Num())
+}
+
+func TestSinker_CursorStore_StopGracefully(t *testing.T) {
+	endpoint := newTestStreamServer(t, func(req *pbsubstreamsrpc.Request, stream pbsubstreamsrpc.Stream_BlocksServer) error {
+		for num := uint64(1); num <= 3; num++ {
+			if err := stream.Send(testCursorDataResponse(num)); err != nil {
+				return err
+			}
+		}
+
+		<-stream.Context().Done()
+		return nil
+	})
+
+	store := &testCursorStore{}
+	inHandler := make(chan struct{})
+	handler := &testFlushHandler{
+		SinkerHandler: NewSinkerHandlers(
+			func(ctx context.Context, data *pbsubstreamsrpc.BlockScopedData, isLive *bool, cursor *Cursor) error {
+				if data.Clock.Number == 3 {
+					close(inHandler)
+				}
+
+				return nil
+			},
+			func(ctx context.Context, undoSignal *pbsubstreamsrpc.BlockUndoSignal, cursor *Cursor) error {
+				return nil
+			},
+		),
+		flush: func(cursor *Cursor) error {
+			assert.Empty(t, store.saved, "cursor saved only once flushed")
+			return nil
+		},
+	}
+
+	sinker := newTestPlaintextSinker(t, endpoint, WithCursorStore(store))
+	go sinker.Run(context.Background(), nil, handler)
+
+	<-inHandler
+	require.NoError(t, sinker.StopGracefully(5*time.Second))
+
+	require.Len(t, store.saved, 1)
+	assert.Equal(t, uint64(3), store.saved[0].Block().Num())
+}
+
+type testCursorStore struct {
+	saved []*Cursor
+}
+
+func (s *testCursorStore) Load(ctx context.Context) (*Cursor, string, error) {
+	return NewBlankCursor(), "", nil
+}
+
+func (s *testCursorStore) Save(ctx context.Context, cursor *Cursor, moduleHash string) error {
+	s.saved = append(s.saved, cursor)
+	return nil
+}
+
+type testModuleHashChangeHandler struct {
+	SinkerHandler
+
+	change *ModuleHashChange
+	err    error
+}
+
+func (h *testModuleHashChangeHandler) HandleModuleHashChange(ctx context.Context, change *ModuleHashChange) error {
+	h.change = change
+	return h.err
+}
@@ -0,0 +1,142 @@
+package sink
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// errPausedDisconnect is returned by [Sinker.doRequest] when the stream is closed because the
+// [Sinker] stayed paused longer than the configured grace period.
+var errPausedDisconnect = errors.New("paused longer than grace period")
+
+// Pause stops dispatching messages to the handler, the handler call in progress, if any,
+// completes normally. The stream stays open, without receiving new messages, unless a grace
+// period is configured with [WithPauseDisconnectAfter] in which case it's closed once the grace
+// period elapsed. The in-memory undo buffer is kept and messages are received again from the
+// last cursor received once [Sinker.Resume] is called.
+//
+// It returns false if the [Sinker] was already paused, the state is visible through
+// [Sinker.Paused], the stats and the [Paused] metric.
+func (s *Sinker) Pause() bool {
+	if !s.pause.Pause() {
+		return false
+	}
+
+	s.logger.Info("sinker paused, handler will not receive messages until resumed", zap.Stringer("last_block", s.stats.LastBlock()))
+	s.stats.RecordPaused(true)
+	Paused.SetUint64(1)
+
+	return true
+}
+
+// Resume resumes dispatching messages to the handler after [Sinker.Pause], reconnecting the
+// stream if it was closed. It returns false if the [Sinker] was not paused.
+func (s *Sinker) Resume() bool {
+	if !s.pause.Resume() {
+		return false
+	}
+
+	s.logger.Info("sinker resumed")
+	s.stats.RecordPaused(false)
+	Paused.SetUint64(0)
+
+	return true
+}
+
+// Paused returns true and the time at which it was paused if the [Sinker] is currently paused,
+// see [Sinker.Pause].
+func (s *Sinker) Paused() (paused bool, since time.Time) {
+	return s.pause.Paused()
+}
+
+// pauseState is the pause state of a [Sinker], see [Sinker.Pause].
+type pauseState struct {
+	mu       sync.Mutex
+	paused   bool
+	pausedAt time.Time
+	resumed  chan struct{}
+}
+
+// Pause pauses, returns false if it was already paused.
+func (p *pauseState) Pause() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.paused {
+		return false
+	}
+
+	p.paused = true
+	p.pausedAt = time.Now()
+	p.resumed = make(chan struct{})
+
+	return true
+}
+
+// Resume resumes, returns false if it was not paused.
+func (p *pauseState) Resume() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.paused {
+		return false
+	}
+
+	p.paused = false
+	close(p.resumed)
+
+	return true
+}
+
+// Paused returns true and the time at which it was paused if it's currently paused.
+func (p *pauseState) Paused() (paused bool, since time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.paused, p.pausedAt
+}
+
+// Wait blocks while paused, it returns [errPausedDisconnect] if still paused after `grace`, a
+// zero `grace` waiting until resumed. It returns nil when resumed or when `ctx` is done.
+func (p *pauseState) Wait(ctx context.Context, grace time.Duration) error {
+	p.mu.Lock()
+	paused, resumed := p.paused, p.resumed
+	p.mu.Unlock()
+
+	if !paused {
+		return nil
+	}
+
+	var graceElapsed <-chan time.Time
+	if grace > 0 {
+		timer := time.NewTimer(grace)
+		defer timer.Stop()
+
+		graceElapsed = timer.C
+	}
+
+	select {
+	case <-resumed:
+	case <-ctx.Done():
+	case <-graceElapsed:
+		return errPausedDisconnect
+	}
+
+	return nil
+}
+
+// waitResumed blocks until the [Sinker] is resumed, `ctx` is done or a graceful stop is
+// requested.
+func (s *Sinker) waitResumed(ctx context.Context) {
+	waitCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stop := context.AfterFunc(s.gracefulStop, cancel)
+	defer stop()
+
+	s.pause.Wait(waitCtx, 0)
+}
@@ -0,0 +1,106 @@
+package sink
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func TestSinker_PauseResume(t *testing.T) {
+	sinker := newTestSinker(t)
+
+	var handled atomic.Int64
+	handler := NewSinkerHandlers(
+		func(ctx context.Context, data *pbsubstreamsrpc.BlockScopedData, isLive *bool, cursor *Cursor) error {
+			if handled.Add(1) == 1 {
+				assert.True(t, sinker.Pause())
+				assert.False(t, sinker.Pause(), "already paused")
+			}
+
+			return nil
+		},
+		func(ctx context.Context, undoSignal *pbsubstreamsrpc.BlockUndoSignal, cursor *Cursor) error {
+			return nil
+		},
+	)
+
+	stream := &fakeBlocksClient{eof: true, messages: []*pbsubstreamsrpc.Response{testDataResponse(1), testDataResponse(2), testDataResponse(3)}}
+
+	done := make(chan error)
+	go func() {
+		_, _, err := sinker.doRequest(context.Background(), nil, &pbsubstreamsrpc.Request{}, &fakeStreamClient{stream}, nil, handler)
+		done <- err
+	}()
+
+	require.Eventually(t, func() bool { paused, _ := sinker.Paused(); return paused }, time.Second, time.Millisecond)
+	assert.Equal(t, float64(1), testutil.ToFloat64(Paused.Native()))
+
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, int64(1), handled.Load(), "no message dispatched while paused")
+
+	assert.True(t, sinker.Resume())
+	assert.False(t, sinker.Resume(), "not paused anymore")
+	assert.Equal(t, float64(0), testutil.ToFloat64(Paused.Native()))
+
+	select {
+	case err := <-done:
+		require.ErrorIs(t, err, io.EOF)
+	case <-time.After(time.Second):
+		t.Fatal("stream not resumed")
+	}
+
+	assert.Equal(t, int64(3), handled.Load())
+}
+
+func TestSinker_PauseDisconnectAfter(t *testing.T) {
+	sinker := newTestSinker(t, WithPauseDisconnectAfter(20*time.Millisecond))
+	handler := NewSinkerHandlers(
+		func(ctx context.Context, data *pbsubstreamsrpc.BlockScopedData, isLive *bool, cursor *Cursor) error {
+			return nil
+		},
+		func(ctx context.Context, undoSignal *pbsubstreamsrpc.BlockUndoSignal, cursor *Cursor) error {
+			return nil
+		},
+	)
+
+	sinker.Pause()
+	defer sinker.Resume()
+
+	stream := &fakeBlocksClient{eof: true, messages: []*pbsubstreamsrpc.Response{testDataResponse(1)}}
+	_, receivedMessage, err := sinker.doRequest(context.Background(), nil, &pbsubstreamsrpc.Request{}, &fakeStreamClient{stream}, nil, handler)
+	require.ErrorIs(t, err, errPausedDisconnect)
+	assert.False(t, receivedMessage)
+	assert.Error(t, stream.ctx.Err(), "stream closed")
+}
+
+func Test_pauseState_Wait(t *testing.T) {
+	state := &pauseState{}
+	assert.NoError(t, state.Wait(context.Background(), 0), "not paused")
+
+	state.Pause()
+	assert.ErrorIs(t, state.Wait(context.Background(), time.Millisecond), errPausedDisconnect)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.NoError(t, state.Wait(ctx, 0), "terminating")
+
+	time.AfterFunc(10*time.Millisecond, func() { state.Resume() })
+	assert.NoError(t, state.Wait(context.Background(), 0), "resumed")
+}
+
+func testDataResponse(num uint64) *pbsubstreamsrpc.Response {
+	return &pbsubstreamsrpc.Response{Message: &pbsubstreamsrpc.Response_BlockScopedData{BlockScopedData: &pbsubstreamsrpc.BlockScopedData{
+		Output: &pbsubstreamsrpc.MapModuleOutput{Name: "kv_out", MapOutput: &anypb.Any{}},
+		Clock:  &pbsubstreams.Clock{Id: fmt.Sprintf("%da", num), Number: num},
+	}}}
+}
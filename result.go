@@ -0,0 +1,34 @@
+package sink
+
+// RunResult describes why [Sinker.RunWithResult] returned.
+type RunResult struct {
+	// Reason is the reason why the sinker terminated.
+	Reason TerminationReason
+	// LastCursor is the cursor of the last block handled, or the cursor the sinker was started
+	// with if none was, it's the cursor to persist to restart from.
+	LastCursor *Cursor
+	// BlocksProcessed is the number of blocks handled by the handler during the run.
+	BlocksProcessed uint64
+	// Err is the error the sinker was shut down with, nil for [TerminationReasonCompleted],
+	// [TerminationReasonStopAtBlock] and [TerminationReasonCanceled]. Use [errors.Is] with
+	// [ErrHandlerFailed], [ErrStreamFailed], [ErrBackOffExpired] or [ErrStoppedGracefully] to
+	// classify it.
+	Err error
+}
+
+// terminate shuts down the sinker with `err` and returns the result of the run, `cursor` being
+// the cursor the sinker was started with.
+func (s *Sinker) terminate(cursor *Cursor, reason TerminationReason, err error) *RunResult {
+	result := &RunResult{Reason: reason, LastCursor: cursor, Err: err}
+
+	s.state.mu.Lock()
+	if s.state.lastHandledCursor != nil {
+		result.LastCursor = s.state.lastHandledCursor
+	}
+	result.BlocksProcessed = s.state.blocksProcessed
+	s.state.mu.Unlock()
+
+	s.Shutdown(err)
+
+	return result
+}
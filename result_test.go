@@ -0,0 +1,157 @@
+package sink
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestSinker_RunWithResult(t *testing.T) {
+	sendBlocks := func(count uint64, end error) func(req *pbsubstreamsrpc.Request, stream pbsubstreamsrpc.Stream_BlocksServer) error {
+		return func(req *pbsubstreamsrpc.Request, stream pbsubstreamsrpc.Stream_BlocksServer) error {
+			for num := uint64(1); num <= count; num++ {
+				if err := stream.Send(testCursorDataResponse(num)); err != nil {
+					return err
+				}
+			}
+
+			return end
+		}
+	}
+
+	tests := []struct {
+		name                string
+		blocks              func(req *pbsubstreamsrpc.Request, stream pbsubstreamsrpc.Stream_BlocksServer) error
+		handlerErr          error
+		completionErr       error
+		expectedReason      TerminationReason
+		expectedErrIs       error
+		expectedMessageType string
+		expectedBlock       uint64
+		expectedBlocks      uint64
+		expectCompleted     bool
+	}{
+		{"completed", sendBlocks(3, nil), nil, nil, TerminationReasonCompleted, nil, "", 3, 3, true},
+		{"completion handler error", sendBlocks(3, nil), nil, errors.New("boom"), TerminationReasonHandlerError, ErrHandlerFailed, MessageTypeBlockRangeCompletion, 3, 3, true},
+		{"handler error", sendBlocks(3, nil), errors.New("boom"), nil, TerminationReasonHandlerError, ErrHandlerFailed, MessageTypeBlockScopedData, 1, 1, false},
+		{"stream failed", sendBlocks(2, status.Error(codes.InvalidArgument, "bad request")), nil, nil, TerminationReasonFailed, ErrStreamFailed, "", 2, 2, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			completed := false
+			handler := &testCompletionHandler{
+				SinkerHandler: NewSinkerHandlers(
+					func(ctx context.Context, data *pbsubstreamsrpc.BlockScopedData, isLive *bool, cursor *Cursor) error {
+						if data.Clock.Number == 2 {
+							return tt.handlerErr
+						}
+
+						return nil
+					},
+					func(ctx context.Context, undoSignal *pbsubstreamsrpc.BlockUndoSignal, cursor *Cursor) error {
+						return nil
+					},
+				),
+				completion: func(cursor *Cursor) error {
+					completed = true
+					return tt.completionErr
+				},
+			}
+
+			sinker := newTestPlaintextSinker(t, newTestStreamServer(t, tt.blocks))
+			result := sinker.RunWithResult(context.Background(), nil, handler)
+
+			assert.Equal(t, tt.expectedReason, result.Reason)
+			assert.Equal(t, tt.expectCompleted, completed)
+			assert.Equal(t, tt.expectedBlocks, result.BlocksProcessed)
+			require.NotNil(t, result.LastCursor)
+			assert.Equal(t, tt.expectedBlock, result.LastCursor.Block().Num())
+			assert.Equal(t, result.Err, sinker.Err())
+
+			if tt.expectedErrIs == nil {
+				assert.NoError(t, result.Err)
+			} else {
+				assert.ErrorIs(t, result.Err, tt.expectedErrIs)
+			}
+
+			var handlerErr *HandlerError
+			if errors.As(result.Err, &handlerErr) {
+				assert.Equal(t, tt.expectedMessageType, handlerErr.MessageType)
+			}
+
+			var streamErr *StreamError
+			if errors.As(result.Err, &streamErr) {
+				assert.Equal(t, codes.InvalidArgument, streamErr.Code)
+			}
+		})
+	}
+}
+
+func TestSinker_RunWithResult_Canceled(t *testing.T) {
+	endpoint := newTestStreamServer(t, func(req *pbsubstreamsrpc.Request, stream pbsubstreamsrpc.Stream_BlocksServer) error {
+		if err := stream.Send(testCursorDataResponse(1)); err != nil {
+			return err
+		}
+
+		<-stream.Context().Done()
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	completed := false
+	handler := &testCompletionHandler{
+		SinkerHandler: NewSinkerHandlers(
+			func(ctx context.Context, data *pbsubstreamsrpc.BlockScopedData, isLive *bool, cursor *Cursor) error {
+				cancel()
+				return nil
+			},
+			func(ctx context.Context, undoSignal *pbsubstreamsrpc.BlockUndoSignal, cursor *Cursor) error {
+				return nil
+			},
+		),
+		completion: func(cursor *Cursor) error {
+			completed = true
+			return nil
+		},
+	}
+
+	cursor := NewBlankCursor()
+	result := newTestPlaintextSinker(t, endpoint).RunWithResult(ctx, cursor, handler)
+
+	assert.Equal(t, TerminationReasonCanceled, result.Reason)
+	assert.NoError(t, result.Err)
+	assert.False(t, completed, "completion handler not called when canceled")
+	assert.Equal(t, uint64(1), result.BlocksProcessed)
+	assert.Equal(t, uint64(1), result.LastCursor.Block().Num())
+}
+
+func TestTerminationReason_MarshalText(t *testing.T) {
+	text, err := TerminationReasonStoppedGracefully.MarshalText()
+	require.NoError(t, err)
+	assert.Equal(t, "StoppedGracefully", string(text))
+
+	var reason TerminationReason
+	require.NoError(t, reason.UnmarshalText([]byte("BackOffExpired")))
+	assert.Equal(t, TerminationReasonBackOffExpired, reason)
+
+	assert.Error(t, reason.UnmarshalText([]byte("Unknown")))
+}
+
+type testCompletionHandler struct {
+	SinkerHandler
+
+	completion func(cursor *Cursor) error
+}
+
+func (h *testCompletionHandler) HandleBlockRangeCompletion(ctx context.Context, cursor *Cursor) error {
+	return h.completion(cursor)
+}
@@ -6,8 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"log"
-	"strings"
+	"sync"
 	"time"
 
 	"github.com/cenkalti/backoff/v4"
@@ -23,6 +22,8 @@ import (
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/keepalive"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/protobuf/proto"
 )
@@ -50,17 +51,44 @@ type Sinker struct {
 	tracer           logging.Tracer
 
 	// Options
-	backOff         backoff.BackOff
-	buffer          *blockDataBuffer
-	blockRange      *bstream.Range
-	infiniteRetry   bool
-	finalBlocksOnly bool
-	livenessChecker LivenessChecker
-	extraHeaders    []string
+	backOff                backoff.BackOff
+	buffer                 *blockDataBuffer
+	requestedBlockRanges   BlockRanges
+	infiniteRetry          bool
+	finalBlocksOnly        bool
+	livenessChecker        LivenessChecker
+	livenessCallback       func(isLive bool, block *pbsubstreams.Clock)
+	extraHeaders           []string
+	authProvider           AuthProvider
+	endpoints              []*Endpoint
+	failoverAfter          int
+	primaryCoolDown        time.Duration
+	tlsConfig              *TLSConfig
+	keepAlive              *keepalive.ClientParameters
+	maxRecvMessageSize     int
+	compression            string
+	stallTimeouts          stallTimeouts
+	pauseDisconnectAfter   time.Duration
+	adminListenAddr        string
+	gracefulStopCallback   func(cursor *Cursor)
+	cursorStore            CursorStore
+	storedModuleHash       string
+	moduleHashChangePolicy ModuleHashChangePolicy
+	dryRunOutput           io.Writer
 
 	// State
+	config                  *SinkerConfig
 	stats                   *Stats
 	requestActiveStartBlock uint64
+	failover                *endpointFailover
+	liveness                *livenessTracker
+	pause                   pauseState
+	state                   runtimeState
+	gracefulStop            context.Context
+	requestGracefulStop     context.CancelFunc
+	decoderOnce             sync.Once
+	decoder                 *outputDecoder
+	decoderErr              error
 }
 
 func New(
@@ -81,6 +109,8 @@ func New(
 		outputModuleHash: hex.EncodeToString(hash),
 		mode:             mode,
 		backOff:          backoff.NewExponentialBackOff(),
+		failoverAfter:    defaultFailoverAfter,
+		primaryCoolDown:  defaultPrimaryCoolDown,
 		stats:            newStats(logger),
 		logger:           logger,
 		tracer:           tracer,
@@ -90,6 +120,61 @@ func New(
 		opt(s)
 	}
 
+	s.gracefulStop, s.requestGracefulStop = context.WithCancel(context.Background())
+
+	if len(s.requestedBlockRanges) == 0 {
+		s.requestedBlockRanges = BlockRanges{NewBlockRange(int64(s.outputModule.InitialBlock), nil)}
+	}
+
+	if err := s.requestedBlockRanges.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid block range: %w", err)
+	}
+
+	s.activateBlockRange(s.requestedBlockRanges[0])
+
+	if _, err := parseHeaders(s.extraHeaders); err != nil {
+		return nil, fmt.Errorf("invalid extra headers: %w", err)
+	}
+
+	if len(s.endpoints) == 0 {
+		s.endpoints = []*Endpoint{{Address: clientConfig.Endpoint(), Insecure: clientConfig.Insecure(), Plaintext: clientConfig.PlainText()}}
+	}
+
+	for _, endpoint := range s.endpoints {
+		if err := endpoint.validate(); err != nil {
+			return nil, fmt.Errorf("invalid endpoints: %w", err)
+		}
+	}
+
+	if err := s.tlsConfig.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid tls config: %w", err)
+	}
+
+	if s.compression != "" && encoding.GetCompressor(s.compression) == nil {
+		return nil, fmt.Errorf("invalid compression: unknown compressor %q, accepted values are %q and %q", s.compression, CompressionGzip, CompressionZstd)
+	}
+
+	if s.maxRecvMessageSize < 0 {
+		return nil, fmt.Errorf("invalid max receive message size: must be positive, got %d", s.maxRecvMessageSize)
+	}
+
+	if s.keepAlive != nil && (s.keepAlive.Time < 0 || s.keepAlive.Timeout < 0) {
+		return nil, fmt.Errorf("invalid keepalive: durations must be positive")
+	}
+
+	if s.stallTimeouts.backprocessing < 0 || s.stallTimeouts.live < 0 {
+		return nil, fmt.Errorf("invalid stall watchdog: timeouts must be positive")
+	}
+
+	if s.failoverAfter < 1 {
+		return nil, fmt.Errorf("invalid endpoint failover: failover after must be at least 1, got %d", s.failoverAfter)
+	}
+	s.failover = newEndpointFailover(s.endpoints, s.failoverAfter, s.primaryCoolDown)
+
+	if s.livenessChecker != nil {
+		s.liveness = &livenessTracker{checker: s.livenessChecker}
+	}
+
 	if s.finalBlocksOnly && s.buffer != nil {
 		s.logger.Debug("discarding undo buffer since final blocks only requested")
 		s.buffer = nil
@@ -101,27 +186,62 @@ func New(
 		zap.String("output_module_name", s.OutputModuleName()),
 		zap.String("output_module_type", s.outputModule.Output.Type),
 		zap.String("output_module_hash", s.outputModuleHash),
-		zap.Stringer("client_config", (*substramsClientStringer)(s.clientConfig)),
+		zap.Stringer("client_config", &substramsClientStringer{s.clientConfig, s.tlsConfig}),
+		zap.Int("endpoint_count", len(s.endpoints)),
+		zap.Stringer("grpc", &grpcSettingsStringer{s.keepAlive, s.maxRecvMessageSize, s.compression}),
 		zap.Stringer("buffer", s.buffer),
-		zap.Stringer("block_range", s.blockRange),
+		zap.Stringer("block_range", s.requestedBlockRanges),
 		zap.Bool("infinite_retry", s.infiniteRetry),
 		zap.Bool("final_blocks_only", s.finalBlocksOnly),
 		zap.Bool("liveness_checker", s.livenessChecker != nil),
+		zap.Stringer("stall_watchdog", s.stallTimeouts),
+		zap.Bool("auth_provider", s.authProvider != nil),
+		zap.Strings("extra_headers", redactHeaders(s.extraHeaders)),
 	)
 
 	return s, nil
 }
 
-type substramsClientStringer client.SubstreamsClientConfig
+type substramsClientStringer struct {
+	config    *client.SubstreamsClientConfig
+	tlsConfig *TLSConfig
+}
 
 func (s *substramsClientStringer) String() string {
-	config := (*client.SubstreamsClientConfig)(s)
+	config := s.config
 
-	return fmt.Sprintf("%s (insecure: %t, plaintext: %t, JWT present: %t)", config.Endpoint(), config.Insecure(), config.PlainText(), config.AuthToken() != "")
+	return fmt.Sprintf("%s (insecure: %t, plaintext: %t, tls: %s, JWT present: %t)", config.Endpoint(), config.Insecure(), config.PlainText(), tlsMode(s.tlsConfig, config.Insecure(), config.PlainText()), config.AuthToken() != "")
 }
 
+// BlockRange returns the absolute block range streamed by this sinker instance, when
+// multiple block range segments are configured, it's the segment currently streamed.
+//
+// If the requested block range start block is relative to the chain's head block, the
+// returned value is nil until the Substreams backend resolved the actual start block
+// when the stream starts, use [Sinker.RequestedBlockRange] to get the range as requested.
 func (s *Sinker) BlockRange() *bstream.Range {
-	return s.blockRange
+	s.state.mu.Lock()
+	defer s.state.mu.Unlock()
+
+	return s.state.blockRange
+}
+
+// RequestedBlockRange returns the block range segment currently streamed as requested when
+// the sinker was configured, its start block can be relative to the chain's head block.
+func (s *Sinker) RequestedBlockRange() *BlockRange {
+	s.state.mu.Lock()
+	defer s.state.mu.Unlock()
+
+	return s.state.requestedBlockRange
+}
+
+// RequestedBlockRanges returns all the block range segments as requested when the sinker
+// was configured, they are streamed sequentially.
+func (s *Sinker) RequestedBlockRanges() BlockRanges {
+	s.state.mu.Lock()
+	defer s.state.mu.Unlock()
+
+	return s.requestedBlockRanges
 }
 
 func (s *Sinker) Package() *pbsubstreams.Package {
@@ -132,8 +252,9 @@ func (s *Sinker) OutputModule() *pbsubstreams.Module {
 	return s.outputModule
 }
 
-// OutputModuleHash returns the module output hash, can be used by consumer
-// to warn if the module changed between restart of the process.
+// OutputModuleHash returns the module output hash, persist it along with the cursor and give
+// it back through [WithStoredModuleHash] to detect that the module changed between restart of
+// the process, see [WithModuleHashChangePolicy].
 func (s *Sinker) OutputModuleHash() string {
 	return s.outputModuleHash
 }
@@ -167,13 +288,29 @@ func (s *Sinker) EndpointConfig() (endpoint string, plaintext bool, insecure boo
 	return s.clientConfig.Endpoint(), s.clientConfig.PlainText(), s.clientConfig.Insecure()
 }
 
+// ActiveEndpoint returns the [Endpoint] this sinker instance currently streams from, see
+// [WithEndpoints].
+func (s *Sinker) ActiveEndpoint() *Endpoint {
+	_, endpoint := s.failover.Active()
+	return endpoint
+}
+
 // ApiToken returns the currently defined ApiToken sets on this sinker instance, ""
 // is no api token was configured
 func (s *Sinker) ApiToken() string {
 	return s.clientConfig.AuthToken()
 }
 
+// Run streams the requested block range and dispatches the messages received to `handler`
+// until the stream completes, `ctx` is canceled or an unrecoverable error occurs, the sinker
+// is then shut down, see [Sinker.RunWithResult] to know why it terminated.
 func (s *Sinker) Run(ctx context.Context, cursor *Cursor, handler SinkerHandler) {
+	s.RunWithResult(ctx, cursor, handler)
+}
+
+// RunWithResult acts like [Sinker.Run] and returns, once the sinker is shut down, the reason
+// why it terminated along with the last cursor handled, see [RunResult].
+func (s *Sinker) RunWithResult(ctx context.Context, cursor *Cursor, handler SinkerHandler) *RunResult {
 	s.OnTerminating(func(_ error) {
 		s.logger.Info("sinker terminating")
 		s.stats.Close()
@@ -187,64 +324,180 @@ func (s *Sinker) Run(ctx context.Context, cursor *Cursor, handler SinkerHandler)
 
 	s.stats.Start(logEach)
 
+	if s.adminListenAddr != "" {
+		if err := s.startAdminServer(s.adminListenAddr); err != nil {
+			return s.terminate(cursor, TerminationReasonFailed, fmt.Errorf("admin server: %w", err))
+		}
+	}
+
+	if s.dryRunOutput != nil {
+		s.logger.Info("dry-run mode, printing decoded module output instead of calling the handler")
+		handler = newDryRunHandler(s.dryRunOutput, s.DecodeAnyJSON)
+	}
+
+	cursor, err := s.resolveStartCursor(ctx, cursor, handler)
+	if err != nil {
+		if errors.Is(err, ErrHandlerFailed) {
+			return s.terminate(cursor, TerminationReasonHandlerError, err)
+		}
+
+		return s.terminate(cursor, TerminationReasonFailed, err)
+	}
+
 	fields := []zap.Field{zap.Duration("stats_refresh_each", logEach)}
 	if cursor != nil {
 		fields = append(fields, zap.Stringer("restarting_at", cursor.Block()))
 	}
-	if s.adjustedEndBlock() != 0 {
-		fields = append(fields, zap.String("end_at", fmt.Sprintf("#%d", s.adjustedEndBlock()-1)))
+
+	// With multiple segments, the first segment boundaries would be misleading so all segments are logged
+	if len(s.requestedBlockRanges) > 1 {
+		fields = append(fields, zap.Stringer("block_ranges", s.requestedBlockRanges))
+	} else {
+		if requestedBlockRange := s.RequestedBlockRange(); requestedBlockRange.IsHeadRelative() {
+			fields = append(fields, zap.String("start_at", fmt.Sprintf("head%d", requestedBlockRange.StartBlock())))
+		}
+		if s.adjustedEndBlock() != 0 {
+			fields = append(fields, zap.String("end_at", fmt.Sprintf("#%d", s.adjustedEndBlock()-1)))
+		}
 	}
 
 	s.logger.Info("starting sinker", fields...)
-	lastCursor, err := s.run(ctx, cursor, handler)
-	if err == nil {
-		s.logger.Info("substreams ended correctly, reached your stop block", zap.Stringer("last_block_seen", lastCursor.Block()))
+	lastCursor, err := s.runBlockRanges(ctx, cursor, handler)
 
-		if v, ok := handler.(SinkerCompletionHandler); ok {
-			s.logger.Info("substreams handler has completion callback defined, calling it")
+	switch {
+	case s.IsTerminating():
+		// Shut down while running, by a graceful stop timeout for example, the shutdown error is the cause
+		return s.terminate(cursor, TerminationReasonShutdown, s.Err())
 
-			if err := v.HandleBlockRangeCompletion(ctx, lastCursor); err != nil {
-				s.Shutdown(fmt.Errorf("sinker completion handler error: %w", err))
-				return
+	case errors.Is(err, errGracefulStop):
+		if err := s.stopGracefully(ctx, handler, cursor); err != nil {
+			if errors.Is(err, ErrHandlerFailed) {
+				return s.terminate(cursor, TerminationReasonHandlerError, err)
 			}
+
+			return s.terminate(cursor, TerminationReasonFailed, err)
+		}
+
+		return s.terminate(cursor, TerminationReasonStoppedGracefully, ErrStoppedGracefully)
+
+	case errors.Is(err, errStopBlockReached):
+		s.logger.Info("substreams stopped at requested block", zap.Stringer("last_block_seen", lastCursor.Block()))
+		return s.terminate(cursor, TerminationReasonStopAtBlock, nil)
+
+	case ctx.Err() != nil:
+		// We are not the cause of the termination, so Shutdown without error, we still shutdown so Sinker last stats is still printed
+		s.logger.Info("substreams canceled", zap.Stringer("last_block_seen", lastCursor.Block()))
+		return s.terminate(cursor, TerminationReasonCanceled, nil)
+
+	case errors.Is(err, ErrBackOffExpired):
+		return s.terminate(cursor, TerminationReasonBackOffExpired, err)
+
+	case errors.Is(err, ErrHandlerFailed):
+		return s.terminate(cursor, TerminationReasonHandlerError, err)
+
+	case err != nil:
+		return s.terminate(cursor, TerminationReasonFailed, err)
+	}
+
+	s.logger.Info("substreams ended correctly, reached your stop block", zap.Stringer("last_block_seen", lastCursor.Block()))
+
+	if v, ok := handler.(SinkerCompletionHandler); ok {
+		s.logger.Info("substreams handler has completion callback defined, calling it")
+
+		if err := v.HandleBlockRangeCompletion(ctx, lastCursor); err != nil {
+			return s.terminate(cursor, TerminationReasonHandlerError, newHandlerError(MessageTypeBlockRangeCompletion, lastCursor, err))
 		}
 	}
 
-	// If the context is canceled and we are here, it we have stop running without any other error, so Shutdown without error,
-	// we are not the cause of the error. We still shutdown so Sinker last stats is still printed.
-	shutdownErr := err
-	if ctx.Err() == context.Canceled {
-		shutdownErr = nil
+	if handledCursor := s.lastHandledCursor(); handledCursor != nil {
+		if err := s.saveCursor(ctx, handledCursor); err != nil {
+			return s.terminate(cursor, TerminationReasonFailed, err)
+		}
 	}
 
-	s.Shutdown(shutdownErr)
+	return s.terminate(cursor, TerminationReasonCompleted, nil)
 }
 
-func (s *Sinker) run(ctx context.Context, cursor *Cursor, handler SinkerHandler) (activeCursor *Cursor, err error) {
+// runBlockRanges runs each requested block range segment sequentially. When restarting from
+// `cursor`, segments that were completed already are skipped and segments starting after
+// `cursor` are started from their start block.
+//
+// Each segment resumes from the cursor of the last block handled, not the last one received,
+// since blocks still in the undo buffer when a segment ends were never given to the handler.
+func (s *Sinker) runBlockRanges(ctx context.Context, cursor *Cursor, handler SinkerHandler) (activeCursor *Cursor, err error) {
 	activeCursor = cursor
+	handledCursor := cursor
 
-	ssClient, closeFunc, callOpts, headers, err := client.NewSubstreamsClient(s.clientConfig)
-	if err != nil {
-		return activeCursor, fmt.Errorf("new substreams client: %w", err)
-	}
-	s.OnTerminating(func(_ error) { closeFunc() })
+	for i, segment := range s.requestedBlockRanges {
+		segmentCursor := handledCursor
+		if !handledCursor.IsBlank() && !segment.IsHeadRelative() {
+			nextBlock := handledCursor.Block().Num() + 1
+
+			if segment.EndBlock() != nil && nextBlock >= *segment.EndBlock() {
+				s.logger.Info("skipping block range segment already completed", zap.Stringer("segment", segment), zap.Stringer("cursor_block", handledCursor.Block()))
+				continue
+			}
 
-	var headersArray []string
-	if len(s.extraHeaders) > 0 {
-		if headers == nil {
-			headers = make(client.Headers)
+			if nextBlock < uint64(segment.StartBlock()) {
+				segmentCursor = NewBlankCursor()
+			}
 		}
 
-		for k, v := range parseHeaders(s.extraHeaders) {
-			headers[k] = v
+		if i > 0 {
+			s.activateBlockRange(segment)
+			s.logger.Info("starting next block range segment", zap.Int("segment_index", i), zap.Stringer("segment", segment))
 		}
 
-		headersArray = make([]string, 0, len(headers)*2)
-		for k, v := range parseHeaders(s.extraHeaders) {
-			headersArray = append(headersArray, k, v)
+		activeCursor, err = s.run(ctx, segmentCursor, handler)
+		if lastHandled := s.lastHandledCursor(); lastHandled != nil {
+			handledCursor = lastHandled
+		}
+
+		if err != nil {
+			return activeCursor, err
+		}
+
+		if ctx.Err() != nil {
+			// We are terminating, the segment has not been completed
+			return activeCursor, nil
+		}
+
+		if v, ok := handler.(SinkerBlockRangeSegmentCompletionHandler); ok {
+			blockRange := s.BlockRange()
+			s.logger.Info("block range segment completed, calling handler segment completion callback", zap.Stringer("segment", blockRange))
+
+			if err := v.HandleBlockRangeSegmentCompletion(ctx, blockRange, handledCursor); err != nil {
+				return activeCursor, newHandlerError(MessageTypeBlockRangeSegmentCompletion, handledCursor, err)
+			}
 		}
 	}
 
+	return activeCursor, nil
+}
+
+// activateBlockRange makes `segment` the block range currently streamed, any buffered
+// block of the previous segment is discarded since it's outside of `segment`.
+func (s *Sinker) activateBlockRange(segment *BlockRange) {
+	s.state.update(func(state *runtimeState) {
+		state.requestedBlockRange = segment
+
+		// Stays nil until the Substreams backend resolves the start block if it's relative to chain's head block
+		state.blockRange = segment.Range()
+
+		// Reassigned under the lock since [Sinker.EffectiveConfig] reads it concurrently
+		if s.buffer != nil {
+			s.buffer = newBlockDataBuffer(s.buffer.Capacity())
+		}
+	})
+}
+
+func (s *Sinker) run(ctx context.Context, cursor *Cursor, handler SinkerHandler) (activeCursor *Cursor, err error) {
+	activeCursor = cursor
+
+	connection := &endpointConnection{}
+	s.OnTerminating(func(_ error) { connection.Close() })
+	defer connection.Close()
+
 	// We will wait at max approximatively 5m before dying
 	backOff := s.backOff
 	s.logger.Debug("configured default backoff", zap.String("back_off", fmt.Sprintf("%#v", backOff)))
@@ -256,12 +509,36 @@ func (s *Sinker) run(ctx context.Context, cursor *Cursor, handler SinkerHandler)
 
 	backOff = backoff.WithContext(backOff, ctx)
 
-	startBlock := s.BlockRange().StartBlock()
 	stopBlock := s.adjustedEndBlock()
 
+	// Credentials are refreshed once after an `Unauthenticated` error, it's allowed again once a message is received
+	authRefreshed := false
+
 	for {
+		if s.failover.RestorePrimary() {
+			s.logger.Info("primary endpoint cool-down elapsed, switching back to it", zap.Stringer("endpoint", s.endpoints[0]))
+		}
+
+		endpointIndex, endpoint := s.failover.Active()
+		if !connection.Connected(endpointIndex) {
+			if err := s.connect(connection, endpointIndex, endpoint); err != nil {
+				return activeCursor, err
+			}
+
+			if len(s.endpoints) > 1 {
+				s.logger.Info("connected to substreams endpoint", zap.Int("endpoint_index", endpointIndex), zap.Stringer("endpoint", endpoint))
+			}
+		}
+
+		// Once resolved by the Substreams backend, a start block relative to chain's head block must
+		// not be re-resolved on reconnection, so we always prefer the resolved block range if known.
+		startBlock := s.RequestedBlockRange().StartBlock()
+		if blockRange := s.BlockRange(); blockRange != nil {
+			startBlock = int64(blockRange.StartBlock())
+		}
+
 		req := &pbsubstreamsrpc.Request{
-			StartBlockNum:   int64(startBlock),
+			StartBlockNum:   startBlock,
 			StopBlockNum:    stopBlock,
 			StartCursor:     activeCursor.String(),
 			FinalBlocksOnly: s.finalBlocksOnly,
@@ -272,16 +549,29 @@ func (s *Sinker) run(ctx context.Context, cursor *Cursor, handler SinkerHandler)
 
 		// Add extra headers if set
 		streamCtx := ctx
-		if len(headersArray) > 0 {
-			streamCtx = metadata.AppendToOutgoingContext(streamCtx, headersArray...)
+		if len(connection.headers) > 0 {
+			streamCtx = metadata.AppendToOutgoingContext(streamCtx, connection.headers...)
 		}
 
 		var receivedMessage bool
-		activeCursor, receivedMessage, err = s.doRequest(streamCtx, activeCursor, req, ssClient, callOpts, handler)
+		err = nil
+		if connection.authProvider != nil && !connection.plaintext {
+			streamCtx, err = appendAuthCredentials(streamCtx, connection.authProvider)
+			if err != nil {
+				err = retryable(fmt.Errorf("auth provider credentials: %w", err))
+			}
+		}
+
+		if err == nil {
+			activeCursor, receivedMessage, err = s.doRequest(streamCtx, activeCursor, req, connection.client, connection.callOpts, handler)
+		}
 
 		// If we received at least one message, we must reset the backoff
 		if receivedMessage {
 			backOff.Reset()
+			authRefreshed = false
+			s.failover.RecordSuccess()
+			s.state.update(func(state *runtimeState) { state.retry.Attempts = 0 })
 		}
 
 		if err != nil {
@@ -299,20 +589,66 @@ func (s *Sinker) run(ctx context.Context, cursor *Cursor, handler SinkerHandler)
 				return activeCursor, nil
 			}
 
+			if errors.Is(err, errPrimaryEndpointAvailable) {
+				// Not an error, the cursor is portable across endpoints so we simply reconnect to the primary endpoint
+				continue
+			}
+
+			if errors.Is(err, errGracefulStop) {
+				return activeCursor, err
+			}
+
+			if errors.Is(err, errForcedReconnect) {
+				// Not an error, we reconnect from scratch right away
+				connection.Close()
+				continue
+			}
+
+			if errors.Is(err, errPausedDisconnect) {
+				s.logger.Info("sinker paused longer than grace period, stream closed until resumed", zap.Duration("grace_period", s.pauseDisconnectAfter))
+				s.waitResumed(ctx)
+
+				// Reconnect from the last cursor received, unless we were terminated while paused
+				continue
+			}
+
 			// Retryable or not, we increment the error counter in all those cases
 			SubstreamsErrorCount.Inc()
 
+			if connection.authProvider != nil && !authRefreshed && isUnauthenticated(err) {
+				authRefreshed = true
+
+				s.logger.Warn("substreams rejected credentials, refreshing them and retrying once", zap.Error(err))
+				if refreshErr := connection.authProvider.Refresh(ctx); refreshErr != nil {
+					return activeCursor, &StreamError{Code: codes.Unauthenticated, Err: fmt.Errorf("refresh auth credentials: %w (after %w)", refreshErr, err)}
+				}
+
+				continue
+			}
+
 			var retryableError *derr.RetryableError
 			if errors.As(err, &retryableError) {
 				s.logger.Error("substreams encountered a retryable error", zap.Error(retryableError.Unwrap()))
 
+				if s.failover.RecordFailure() {
+					_, next := s.failover.Active()
+					s.logger.Warn("substreams endpoint failed repeatedly, switching to next endpoint", zap.Stringer("from", endpoint), zap.Stringer("to", next))
+					EndpointFailoverCount.Inc()
+				}
+
 				sleepFor := backOff.NextBackOff()
+				s.recordRetry(retryableError.Unwrap(), sleepFor)
 				if sleepFor == backoff.Stop {
 					return activeCursor, fmt.Errorf("%w: %w", ErrBackOffExpired, retryableError.Unwrap())
 				}
 
 				s.logger.Info("sleeping before re-connecting", zap.Duration("sleep", sleepFor))
-				time.Sleep(sleepFor)
+				select {
+				case <-time.After(sleepFor):
+				case <-s.gracefulStop.Done():
+					return activeCursor, errGracefulStop
+				}
+				s.state.update(func(state *runtimeState) { state.retry.NextAttemptAt = nil })
 			} else {
 				// Let's not wrap the error, it's not retryable to user will see directly his own error
 				return activeCursor, err
@@ -324,11 +660,12 @@ func (s *Sinker) run(ctx context.Context, cursor *Cursor, handler SinkerHandler)
 // When an undo buffer is used, we most finished +N block later than real
 // stop block to ensure we accumulate enough blocks to assert "finality".
 func (s *Sinker) adjustedEndBlock() (endBlock uint64) {
-	if s.blockRange == nil || s.blockRange.EndBlock() == nil {
+	requestedEndBlock := s.RequestedBlockRange().EndBlock()
+	if requestedEndBlock == nil {
 		return 0
 	}
 
-	endBlock = *s.blockRange.EndBlock()
+	endBlock = *requestedEndBlock
 	if s.buffer != nil {
 		adjusted := endBlock + uint64(s.buffer.Capacity())
 		s.logger.Debug("adjusted request end block for buffer", zap.Uint64("initial", endBlock), zap.Uint64("adjusted", adjusted))
@@ -352,18 +689,61 @@ func (s *Sinker) doRequest(
 	s.logger.Debug("launching substreams request", zap.Int64("start_block", req.StartBlockNum), zap.Stringer("cursor", activeCursor))
 	receivedMessage := false
 
-	stream, err := ssClient.Blocks(ctx, req, callOpts...)
+	// The handler keeps receiving `ctx`, only the stream is canceled on reconnection request or when it stalls
+	reconnectCtx, cancelStream := context.WithCancelCause(ctx)
+	s.state.update(func(state *runtimeState) { state.cancelStream = cancelStream })
+	defer func() {
+		s.state.update(func(state *runtimeState) { state.cancelStream = nil })
+		cancelStream(nil)
+	}()
+
+	streamCtx, watchdog := newStallWatchdog(reconnectCtx, s.stallTimeouts.backprocessing, s.stallTimeouts.live)
+	defer watchdog.Close()
+
+	watchdog.Arm()
+	stream, err := ssClient.Blocks(streamCtx, req, callOpts...)
+	watchdog.Disarm()
 	if err != nil {
+		if stalled := watchdog.Stalled(); stalled != nil {
+			StallWatchdogReconnectCount.Inc()
+			return activeCursor, receivedMessage, retryable(stalled)
+		}
+
 		return activeCursor, receivedMessage, retryable(fmt.Errorf("call sf.substreams.rpc.v2.Stream/Blocks: %w", err))
 	}
 
 	for {
+		// Checked between messages so that the handler call in progress always completes
+		if err := s.pause.Wait(streamCtx, s.pauseDisconnectAfter); err != nil {
+			return activeCursor, receivedMessage, err
+		}
+
+		if s.gracefulStopRequested() {
+			return activeCursor, receivedMessage, errGracefulStop
+		}
+
+		// Checked between messages so that the stream is never interrupted while the handler is processing
+		if s.failover.PrimaryAvailable() {
+			return activeCursor, receivedMessage, errPrimaryEndpointAvailable
+		}
+
 		if s.tracer.Enabled() {
 			s.logger.Debug("substreams waiting to receive message", zap.Stringer("cursor", activeCursor))
 		}
 
+		watchdog.Arm()
 		resp, err := stream.Recv()
+		watchdog.Disarm()
 		if err != nil {
+			if stalled := watchdog.Stalled(); stalled != nil {
+				StallWatchdogReconnectCount.Inc()
+				return activeCursor, receivedMessage, retryable(stalled)
+			}
+
+			if cause := context.Cause(reconnectCtx); errors.Is(cause, errForcedReconnect) || errors.Is(cause, errGracefulStop) {
+				return activeCursor, receivedMessage, cause
+			}
+
 			if errors.Is(err, io.EOF) {
 				return activeCursor, receivedMessage, err
 			}
@@ -371,10 +751,10 @@ func (s *Sinker) doRequest(
 			if dgrpcError := dgrpc.AsGRPCError(err); dgrpcError != nil {
 				switch dgrpcError.Code() {
 				case codes.Unauthenticated:
-					return activeCursor, receivedMessage, fmt.Errorf("stream failure: %w", err)
+					return activeCursor, receivedMessage, &StreamError{Code: dgrpcError.Code(), Err: err}
 
 				case codes.InvalidArgument:
-					return activeCursor, receivedMessage, fmt.Errorf("stream invalid: %w", err)
+					return activeCursor, receivedMessage, &StreamError{Code: dgrpcError.Code(), Err: err}
 
 				}
 			}
@@ -444,11 +824,13 @@ func (s *Sinker) doRequest(
 
 			// We record our stats before the buffer action, so user sees state of "stream" and not state of buffer
 			s.stats.RecordBlock(block)
+			s.state.update(func(state *runtimeState) { state.lastReceivedBlock = block })
 			HeadBlockNumber.SetUint64(block.Num())
 			HeadBlockTimeDrift.SetBlockTime(r.BlockScopedData.Clock.Timestamp.AsTime())
 			DataMessageCount.Inc()
 			DataMessageSizeBytes.AddInt(proto.Size(r.BlockScopedData))
 			BackprocessingCompletion.SetUint64(1)
+			watchdog.MarkLive()
 
 			cursor, err := NewCursor(r.BlockScopedData.Cursor)
 			if err != nil {
@@ -466,6 +848,9 @@ func (s *Sinker) doRequest(
 				if err != nil {
 					return activeCursor, receivedMessage, fmt.Errorf("buffer add block data: %w", err)
 				}
+
+				bufferSummary := s.buffer.Summary()
+				s.state.update(func(state *runtimeState) { state.buffer = bufferSummary })
 			}
 
 			for _, blockScopedData := range dataToProcess {
@@ -475,15 +860,26 @@ func (s *Sinker) doRequest(
 				}
 
 				var isLive *bool
-				if s.livenessChecker != nil {
+				if s.liveness != nil {
+					live, transitioned := s.liveness.Check(blockScopedData)
+					if transitioned {
+						if err := s.onLivenessTransition(ctx, handler, live, blockScopedData.Clock); err != nil {
+							return activeCursor, receivedMessage, &HandlerError{MessageType: MessageTypeLivenessTransition, Block: clockToBlockRef(blockScopedData.Clock), Err: err}
+						}
+					}
+
 					isLive = &blockNotLive
-					if s.livenessChecker.IsLive(blockScopedData.Clock) {
+					if live {
 						isLive = &liveBlock
 					}
 				}
 
 				if err := handler.HandleBlockScopedData(ctx, blockScopedData, isLive, currentCursor); err != nil {
-					return activeCursor, receivedMessage, fmt.Errorf("handle BlockScopedData message at block %s: %w", block, err)
+					return activeCursor, receivedMessage, &HandlerError{MessageType: MessageTypeBlockScopedData, Block: clockToBlockRef(blockScopedData.Clock), Err: err}
+				}
+
+				if s.recordHandled(currentCursor, clockToBlockRef(blockScopedData.Clock), isLive) {
+					return activeCursor, receivedMessage, errStopBlockReached
 				}
 			}
 
@@ -504,14 +900,19 @@ func (s *Sinker) doRequest(
 
 			// We record our stats before the buffer action, so user sees state of "stream" and not state of buffer
 			s.stats.RecordBlock(block)
+			s.state.update(func(state *runtimeState) { state.lastReceivedBlock = block })
 			UndoMessageCount.Inc()
 			HeadBlockNumber.SetUint64(block.Num())
 			// We don't have the block time in undo case for now, so we don't change it
 
 			if s.buffer == nil {
 				if err := handler.HandleBlockUndoSignal(ctx, r.BlockUndoSignal, activeCursor); err != nil {
-					return activeCursor, receivedMessage, fmt.Errorf("handle BlockUndoSignal: %w", err)
+					return activeCursor, receivedMessage, &HandlerError{MessageType: MessageTypeBlockUndoSignal, Block: block, Err: err}
 				}
+
+				s.state.update(func(state *runtimeState) {
+					state.lastHandledCursor, state.lastHandledBlock = activeCursor, block
+				})
 			} else {
 				// In the case of dealing with an undo buffer, it's expected that a fork will never
 				// go beyong the first block in the buffer because if it does, `s.buffer.HandleBlockUndoSignal` here
@@ -522,6 +923,9 @@ func (s *Sinker) doRequest(
 				if err != nil {
 					return activeCursor, receivedMessage, fmt.Errorf("buffer undo block: %w", err)
 				}
+
+				bufferSummary := s.buffer.Summary()
+				s.state.update(func(state *runtimeState) { state.buffer = bufferSummary })
 			}
 
 		case *pbsubstreamsrpc.Response_DebugSnapshotData, *pbsubstreamsrpc.Response_DebugSnapshotComplete:
@@ -536,6 +940,21 @@ func (s *Sinker) doRequest(
 			)
 			s.requestActiveStartBlock = r.Session.ResolvedStartBlock
 
+			if s.BlockRange() == nil {
+				var requestedBlockRange *BlockRange
+				var blockRange *bstream.Range
+				s.state.update(func(state *runtimeState) {
+					requestedBlockRange = state.requestedBlockRange
+					blockRange = requestedBlockRange.Resolve(r.Session.ResolvedStartBlock)
+					state.blockRange = blockRange
+				})
+
+				s.logger.Info("resolved start block relative to chain's head block",
+					zap.Int64("requested_start_block", requestedBlockRange.StartBlock()),
+					zap.Stringer("block_range", blockRange),
+				)
+			}
+
 		default:
 			s.logger.Info("received unknown type of message", zap.Reflect("message", r))
 			UnknownMessageCount.Inc()
@@ -543,10 +962,34 @@ func (s *Sinker) doRequest(
 	}
 }
 
+func (s *Sinker) onLivenessTransition(ctx context.Context, handler SinkerHandler, isLive bool, block *pbsubstreams.Clock) error {
+	s.logger.Info("chain liveness changed", zap.Bool("live", isLive), zap.Uint64("block_num", block.GetNumber()), zap.String("block_id", block.GetId()))
+
+	if s.livenessCallback != nil {
+		s.livenessCallback(isLive, block)
+	}
+
+	livenessHandler, ok := handler.(SinkerLivenessHandler)
+	if !ok {
+		return nil
+	}
+
+	if isLive {
+		return livenessHandler.OnLive(ctx, block)
+	}
+
+	return livenessHandler.OnNotLive(ctx, block)
+}
+
 func stageString(i uint32) string {
 	return fmt.Sprintf("stage %d", i)
 }
 
+func isUnauthenticated(err error) bool {
+	grpcError := dgrpc.AsGRPCError(err)
+	return grpcError != nil && grpcError.Code() == codes.Unauthenticated
+}
+
 func retryable(err error) error {
 	return derr.NewRetryableError(err)
 }
@@ -555,19 +998,3 @@ var (
 	liveBlock    bool = true
 	blockNotLive bool = false
 )
-
-func parseHeaders(headers []string) map[string]string {
-	if headers == nil {
-		return nil
-	}
-
-	result := make(map[string]string)
-	for _, header := range headers {
-		parts := strings.Split(header, ":")
-		if len(parts) != 2 {
-			log.Fatalf("invalid header format: %s", header)
-		}
-		result[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
-	}
-	return result
-}
@@ -0,0 +1,676 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/streamingfast/logging"
+	"github.com/streamingfast/substreams/client"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+var endpointPortRegex = regexp.MustCompile(":[0-9]{2,5}$")
+
+// defaultKeepAliveTimeout is the keepalive timeout used when only the keepalive time is configured
+const defaultKeepAliveTimeout = 20 * time.Second
+
+// SinkerConfig holds every setting needed to construct a [Sinker] through [NewFromConfig], it's
+// the configuration file counterpart of the flags defined by [AddFlagsToSet] and the positional
+// arguments of [NewFromViper].
+//
+// Use [NewDefaultSinkerConfig] to get a config populated with the same defaults as the flags,
+// [ReadSinkerConfig] decodes a YAML or JSON file on top of those defaults.
+//
+// Options taking Go values have no config field and are code-only, pass them to [NewFromConfig]:
+// [WithAuthProvider] for custom providers and [Endpoint.AuthProvider], a custom [CursorStore],
+// [WithStoredModuleHash], [WithGracefulStopCallback], [WithLivenessTransitionCallback], a
+// [LivenessChecker] other than the [DeltaLivenessChecker], a [backoff.BackOff] other than the
+// exponential one and a [WithDryRun] output other than the standard output.
+type SinkerConfig struct {
+	// Endpoint is the Substreams endpoint to connect to, in the form `<host>:<port>`.
+	Endpoint string `yaml:"endpoint" json:"endpoint"`
+	// Insecure skips certificate validation on gRPC connection, see flag `--insecure`.
+	Insecure bool `yaml:"insecure" json:"insecure"`
+	// Plaintext establishes gRPC connection in plaintext, see flag `--plaintext`.
+	Plaintext bool `yaml:"plaintext" json:"plaintext"`
+	// TLS customizes the TLS settings like a custom CA or a client certificate, see [TLSConfig].
+	TLS *TLSConfig `yaml:"tls,omitempty" json:"tls,omitempty"`
+	// GRPCKeepAliveTime is the inactivity after which a keepalive ping is sent, 0 keeps the default, see [WithKeepAlive].
+	GRPCKeepAliveTime Duration `yaml:"grpc_keepalive_time,omitempty" json:"grpc_keepalive_time,omitempty"`
+	// GRPCKeepAliveTimeout is how long to wait for a keepalive ping acknowledgement, defaults to 20s, see [WithKeepAlive].
+	GRPCKeepAliveTimeout Duration `yaml:"grpc_keepalive_timeout,omitempty" json:"grpc_keepalive_timeout,omitempty"`
+	// GRPCMaxRecvMessageSize is the maximum size in bytes of a received message, 0 keeps the default, see [WithMaxRecvMessageSize].
+	GRPCMaxRecvMessageSize int `yaml:"grpc_max_recv_message_size,omitempty" json:"grpc_max_recv_message_size,omitempty"`
+	// GRPCCompression is the stream's compressor, `gzip` or `zstd`, see [WithCompression].
+	GRPCCompression string `yaml:"grpc_compression,omitempty" json:"grpc_compression,omitempty"`
+	// Headers are additional headers sent in the Substreams request in the form `key: value`, see flag `--header`.
+	Headers []string `yaml:"headers,omitempty" json:"headers,omitempty"`
+	// Auth configures how credentials are obtained, see [AuthConfig].
+	Auth *AuthConfig `yaml:"auth,omitempty" json:"auth,omitempty"`
+	// FallbackEndpoints are streamed from, in order, when Endpoint fails repeatedly, see [WithEndpoints].
+	FallbackEndpoints []*EndpointConfig `yaml:"fallback_endpoints,omitempty" json:"fallback_endpoints,omitempty"`
+	// EndpointFailoverAfter is the number of consecutive failures before switching endpoint, 0 keeps the default, see [WithEndpointFailover].
+	EndpointFailoverAfter int `yaml:"endpoint_failover_after,omitempty" json:"endpoint_failover_after,omitempty"`
+	// EndpointPrimaryCoolDown is the delay before switching back to Endpoint, 0 keeps the default, see [WithEndpointFailover].
+	EndpointPrimaryCoolDown Duration `yaml:"endpoint_primary_cool_down,omitempty" json:"endpoint_primary_cool_down,omitempty"`
+
+	// ManifestPath is the path to the Substreams manifest or package, see [NewFromViper] for how it's resolved.
+	ManifestPath string `yaml:"manifest" json:"manifest"`
+	// OutputModule is the output module's name, when empty it's inferred from the package's sink module.
+	OutputModule string `yaml:"output_module" json:"output_module"`
+	// ExpectedOutputModuleType is the expected output module's Protobuf type, see [ReadManifestAndModule].
+	ExpectedOutputModuleType string `yaml:"expected_output_module_type,omitempty" json:"expected_output_module_type,omitempty"`
+	// Network overrides the default network of the manifest, see flag `--network`.
+	Network string `yaml:"network,omitempty" json:"network,omitempty"`
+	// Params are the modules params in the form `<module>=<value>`, see flag `--params`.
+	Params []string `yaml:"params,omitempty" json:"params,omitempty"`
+	// SkipPackageValidation skips package validation, see flag `--skip-package-validation`.
+	SkipPackageValidation bool `yaml:"skip_package_validation" json:"skip_package_validation"`
+
+	// BlockRange is the block range to stream, see [ReadBlockRangesWithTimeResolver] for the accepted syntax.
+	BlockRange string `yaml:"block_range" json:"block_range"`
+	// DevelopmentMode enables Substreams development mode, see flag `--development-mode`.
+	DevelopmentMode bool `yaml:"development_mode" json:"development_mode"`
+	// FinalBlocksOnly streams only final blocks, see flag `--final-blocks-only` and [WithFinalBlocksOnly].
+	FinalBlocksOnly bool `yaml:"final_blocks_only" json:"final_blocks_only"`
+	// UndoBufferSize is the number of blocks buffered to handle forks, 0 disables it, see [WithBlockDataBuffer].
+	UndoBufferSize int `yaml:"undo_buffer_size" json:"undo_buffer_size"`
+	// LiveBlockTimeDelta configures a [DeltaLivenessChecker], 0 disables it, see [WithLivenessChecker].
+	LiveBlockTimeDelta Duration `yaml:"live_block_time_delta" json:"live_block_time_delta"`
+	// StallTimeoutBackprocessing reconnects when no message is received within this delay while backprocessing, 0 disables it, see [WithStallWatchdog].
+	StallTimeoutBackprocessing Duration `yaml:"stall_timeout_backprocessing,omitempty" json:"stall_timeout_backprocessing,omitempty"`
+	// StallTimeoutLive reconnects when no message is received within this delay once live, 0 disables it, see [WithStallWatchdog].
+	StallTimeoutLive Duration `yaml:"stall_timeout_live,omitempty" json:"stall_timeout_live,omitempty"`
+	// PauseDisconnectAfter closes the stream when paused longer than this delay, 0 keeps it open, see [WithPauseDisconnectAfter].
+	PauseDisconnectAfter Duration `yaml:"pause_disconnect_after,omitempty" json:"pause_disconnect_after,omitempty"`
+	// CursorFile is the path of a [FileCursorStore] the cursor is saved to and loaded from, see [WithCursorStore].
+	CursorFile string `yaml:"cursor_file,omitempty" json:"cursor_file,omitempty"`
+	// ModuleHashChangePolicy is `Fail`, `Warn` or `Reset`, defaults to `Fail`, see [WithModuleHashChangePolicy].
+	ModuleHashChangePolicy ModuleHashChangePolicy `yaml:"module_hash_change_policy,omitempty" json:"module_hash_change_policy,omitempty"`
+	// AdminListenAddr serves the admin API on this address, empty disables it, see [WithAdminServer].
+	AdminListenAddr string `yaml:"admin_listen_addr,omitempty" json:"admin_listen_addr,omitempty"`
+	// DryRun prints the decoded module output instead of calling the handler, see [WithDryRun].
+	DryRun bool `yaml:"dry_run,omitempty" json:"dry_run,omitempty"`
+	// InfiniteRetry retries forever instead of giving up after 15 retries, see [WithInfiniteRetry].
+	InfiniteRetry bool `yaml:"infinite_retry" json:"infinite_retry"`
+	// RetryBackOff configures an exponential back off used between retries, see [WithRetryBackOff].
+	RetryBackOff *RetryBackOffConfig `yaml:"retry_backoff,omitempty" json:"retry_backoff,omitempty"`
+}
+
+// AuthConfig configures the [AuthProvider] used to authenticate against the Substreams endpoint,
+// the first configured source wins in this order:
+//
+//   - TokenFile, a [FileAuthProvider] reading the JWT from the file
+//   - Command, a [CommandAuthProvider] running the credential helper command printing the JWT
+//   - Environment variable `SUBSTREAMS_API_KEY`, an [APIKeyAuthProvider] exchanging it against URL
+//   - Environment variable `SUBSTREAMS_API_TOKEN` (or `SF_API_TOKEN`), a [StaticAuthProvider]
+type AuthConfig struct {
+	// TokenFile is the path of a file holding the JWT, the file is read again when it changes, see flag `--auth-token-file`.
+	TokenFile string `yaml:"token_file,omitempty" json:"token_file,omitempty"`
+	// Command is a credential helper command and its arguments printing the JWT on its standard output, see flag `--auth-command`.
+	Command []string `yaml:"command,omitempty" json:"command,omitempty"`
+	// URL is the auth service used to exchange the API key for a JWT, defaults to [DefaultAuthURL], see flag `--auth-url`.
+	URL string `yaml:"url,omitempty" json:"url,omitempty"`
+}
+
+// EndpointConfig is the configuration of a fallback [Endpoint], it uses the [SinkerConfig]'s
+// credentials and its TLS settings when it has none of its own.
+type EndpointConfig struct {
+	// Endpoint is the endpoint's address in the form `<host>:<port>`.
+	Endpoint string `yaml:"endpoint" json:"endpoint"`
+	// Insecure skips certificate validation on gRPC connection.
+	Insecure bool `yaml:"insecure,omitempty" json:"insecure,omitempty"`
+	// Plaintext establishes gRPC connection in plaintext.
+	Plaintext bool `yaml:"plaintext,omitempty" json:"plaintext,omitempty"`
+	// TLS customizes the TLS settings of this endpoint, see [TLSConfig].
+	TLS *TLSConfig `yaml:"tls,omitempty" json:"tls,omitempty"`
+}
+
+func (c *EndpointConfig) endpoint() *Endpoint {
+	return &Endpoint{Address: c.Endpoint, Insecure: c.Insecure, Plaintext: c.Plaintext, TLS: c.TLS.clone()}
+}
+
+// RetryBackOffConfig is the configuration of the exponential back off used between retries, zero
+// values are replaced by the [backoff.ExponentialBackOff] defaults.
+type RetryBackOffConfig struct {
+	InitialInterval Duration `yaml:"initial_interval,omitempty" json:"initial_interval,omitempty"`
+	MaxInterval     Duration `yaml:"max_interval,omitempty" json:"max_interval,omitempty"`
+	MaxElapsedTime  Duration `yaml:"max_elapsed_time,omitempty" json:"max_elapsed_time,omitempty"`
+}
+
+// NewDefaultSinkerConfig returns a [SinkerConfig] with the same defaults as the flags defined
+// by [AddFlagsToSet].
+func NewDefaultSinkerConfig() *SinkerConfig {
+	return &SinkerConfig{
+		BlockRange:         ":",
+		UndoBufferSize:     12,
+		LiveBlockTimeDelta: Duration(300 * time.Second),
+	}
+}
+
+// ReadSinkerConfig reads the config file at `path` on top of [NewDefaultSinkerConfig], a file
+// with extension `.json` is decoded as JSON, anything else as YAML. Unknown fields are rejected.
+func ReadSinkerConfig(path string) (*SinkerConfig, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config %q: %w", path, err)
+	}
+
+	config := NewDefaultSinkerConfig()
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		decoder := json.NewDecoder(bytes.NewReader(content))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(config)
+	} else {
+		decoder := yaml.NewDecoder(bytes.NewReader(content))
+		decoder.KnownFields(true)
+		err = decoder.Decode(config)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("decode config %q: %w", path, err)
+	}
+
+	return config, nil
+}
+
+// Validate validates the config, all errors found are reported at once.
+func (c *SinkerConfig) Validate() error {
+	var errs []error
+
+	if c.Endpoint == "" {
+		errs = append(errs, errors.New("endpoint is required"))
+	} else if !endpointPortRegex.MatchString(c.Endpoint) {
+		errs = append(errs, fmt.Errorf("endpoint %q must be in the form '<host>:<port>'", c.Endpoint))
+	}
+
+	if c.Insecure && c.Plaintext {
+		errs = append(errs, errors.New("insecure and plaintext are mutually exclusive"))
+	}
+
+	if c.TLS.isSet() && c.Plaintext {
+		errs = append(errs, errors.New("tls settings and plaintext are mutually exclusive"))
+	}
+
+	if err := c.TLS.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+
+	for _, endpoint := range c.FallbackEndpoints {
+		if err := endpoint.endpoint().validate(); err != nil {
+			errs = append(errs, fmt.Errorf("fallback %w", err))
+		}
+	}
+
+	if c.EndpointFailoverAfter < 0 || c.EndpointPrimaryCoolDown < 0 {
+		errs = append(errs, errors.New("endpoint failover after and primary cool down must be positive"))
+	}
+
+	if c.StallTimeoutBackprocessing < 0 || c.StallTimeoutLive < 0 {
+		errs = append(errs, errors.New("stall timeouts must be positive"))
+	}
+
+	if c.GRPCKeepAliveTime < 0 || c.GRPCKeepAliveTimeout < 0 {
+		errs = append(errs, errors.New("grpc keepalive durations must be positive"))
+	}
+
+	if c.GRPCMaxRecvMessageSize < 0 {
+		errs = append(errs, fmt.Errorf("grpc max receive message size must be positive, got %d", c.GRPCMaxRecvMessageSize))
+	}
+
+	if c.GRPCCompression != "" && c.GRPCCompression != CompressionGzip && c.GRPCCompression != CompressionZstd {
+		errs = append(errs, fmt.Errorf("grpc compression %q must be one of %q or %q", c.GRPCCompression, CompressionGzip, CompressionZstd))
+	}
+
+	for _, header := range c.Headers {
+		if _, _, err := parseHeader(header); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	for _, param := range c.Params {
+		if module, _, found := strings.Cut(param, "="); !found || module == "" {
+			errs = append(errs, fmt.Errorf("param %q must be in the form '<module>=<value>'", param))
+		}
+	}
+
+	if c.Auth != nil && c.Auth.TokenFile != "" && len(c.Auth.Command) > 0 {
+		errs = append(errs, errors.New("auth token file and auth command are mutually exclusive"))
+	}
+
+	if c.UndoBufferSize < 0 {
+		errs = append(errs, fmt.Errorf("undo buffer size must be positive, got %d", c.UndoBufferSize))
+	}
+
+	if c.PauseDisconnectAfter < 0 {
+		errs = append(errs, fmt.Errorf("pause disconnect after must be positive, got %s", c.PauseDisconnectAfter))
+	}
+
+	if _, found := _ModuleHashChangePolicyMap[c.ModuleHashChangePolicy]; !found {
+		errs = append(errs, fmt.Errorf("module hash change policy %s must be one of %q", c.ModuleHashChangePolicy, ModuleHashChangePolicyNames()))
+	}
+
+	if c.LiveBlockTimeDelta < 0 {
+		errs = append(errs, fmt.Errorf("live block time delta must be positive, got %s", c.LiveBlockTimeDelta))
+	}
+
+	if c.RetryBackOff != nil {
+		if c.RetryBackOff.InitialInterval < 0 || c.RetryBackOff.MaxInterval < 0 || c.RetryBackOff.MaxElapsedTime < 0 {
+			errs = append(errs, errors.New("retry back off durations must be positive"))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// ToYAML returns the YAML representation of the config, suitable for [ReadSinkerConfig].
+func (c *SinkerConfig) ToYAML() ([]byte, error) {
+	return yaml.Marshal(c)
+}
+
+// ToJSON returns the JSON representation of the config, suitable for [ReadSinkerConfig].
+func (c *SinkerConfig) ToJSON() ([]byte, error) {
+	return json.MarshalIndent(c, "", "  ")
+}
+
+func (c *SinkerConfig) clone() *SinkerConfig {
+	cloned := *c
+	cloned.Headers = append([]string(nil), c.Headers...)
+	cloned.Params = append([]string(nil), c.Params...)
+	cloned.TLS = c.TLS.clone()
+	if c.FallbackEndpoints != nil {
+		cloned.FallbackEndpoints = make([]*EndpointConfig, len(c.FallbackEndpoints))
+		for i, endpoint := range c.FallbackEndpoints {
+			endpointConfig := *endpoint
+			endpointConfig.TLS = endpoint.TLS.clone()
+			cloned.FallbackEndpoints[i] = &endpointConfig
+		}
+	}
+	if c.Auth != nil {
+		auth := *c.Auth
+		auth.Command = append([]string(nil), c.Auth.Command...)
+		cloned.Auth = &auth
+	}
+	if c.RetryBackOff != nil {
+		retryBackOff := *c.RetryBackOff
+		cloned.RetryBackOff = &retryBackOff
+	}
+
+	return &cloned
+}
+
+func (c *RetryBackOffConfig) backOff() backoff.BackOff {
+	backOff := backoff.NewExponentialBackOff()
+	if c.InitialInterval > 0 {
+		backOff.InitialInterval = time.Duration(c.InitialInterval)
+	}
+	if c.MaxInterval > 0 {
+		backOff.MaxInterval = time.Duration(c.MaxInterval)
+	}
+	if c.MaxElapsedTime > 0 {
+		backOff.MaxElapsedTime = time.Duration(c.MaxElapsedTime)
+	}
+
+	return backOff
+}
+
+// NewFromConfig constructs a new Sinker instance from a [SinkerConfig], it's the configuration
+// file counterpart of [NewFromViper] and behaves exactly the same way. The config is validated
+// first and all validation errors are reported at once.
+//
+// Credentials are obtained through an [AuthProvider] configured by [SinkerConfig.Auth], by default
+// the API token is read from environment variable `SUBSTREAMS_API_TOKEN` (or `SF_API_TOKEN`).
+//
+// The `ctx` is used while creating the sinker only, to resolve date and time boundaries of the
+// block range against the endpoint. The `opts` are applied after the ones derived from the
+// config, so they take precedence.
+func NewFromConfig(
+	ctx context.Context,
+	config *SinkerConfig,
+	zlog *zap.Logger,
+	tracer logging.Tracer,
+	opts ...Option,
+) (*Sinker, error) {
+	redacted := config.clone()
+	redacted.Headers = redactHeaders(config.Headers)
+	zlog.Info("sinker from config", zap.Reflect("config", redacted))
+
+	return newFromConfig(ctx, config, zlog, tracer, opts...)
+}
+
+func newFromConfig(
+	ctx context.Context,
+	config *SinkerConfig,
+	zlog *zap.Logger,
+	tracer logging.Tracer,
+	opts ...Option,
+) (*Sinker, error) {
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
+	outputModuleName := config.OutputModule
+	if outputModuleName == "" {
+		outputModuleName = InferOutputModuleFromPackage
+	}
+
+	pkg, module, outputModuleHash, err := ReadManifestAndModule(
+		config.ManifestPath,
+		config.Network,
+		config.Params,
+		outputModuleName,
+		config.ExpectedOutputModuleType,
+		config.SkipPackageValidation,
+		zlog,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("reading manifest: %w", err)
+	}
+
+	undoBufferSize := config.UndoBufferSize
+	if config.FinalBlocksOnly {
+		zlog.Debug("override undo buffer size to 0 since final blocks only is requested")
+		undoBufferSize = 0
+	}
+
+	// The initial credentials are used to resolve the block range, the sinker consults the provider itself afterward
+	apiToken, authType := "", client.JWT
+	authProvider := config.authProvider()
+	if authProvider != nil {
+		apiToken, authType, err = authProvider.Credentials(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("auth credentials: %w", err)
+		}
+	}
+
+	clientConfig := client.NewSubstreamsClientConfig(
+		config.Endpoint,
+		apiToken,
+		authType,
+		config.Insecure,
+		config.Plaintext,
+	)
+
+	resolvedBlockRanges, err := ReadBlockRangesWithTimeResolver(ctx, module, config.BlockRange, NewSubstreamsBlockTimeResolver(clientConfig, pkg, module, zlog).WithTLSConfig(config.TLS))
+	if err != nil {
+		return nil, fmt.Errorf("resolve block range: %w", err)
+	}
+	zlog.Debug("resolved block range", zap.Stringer("range", resolvedBlockRanges))
+
+	mode := SubstreamsModeProduction
+	if config.DevelopmentMode {
+		mode = SubstreamsModeDevelopment
+	}
+
+	var defaultSinkOptions []Option
+	if undoBufferSize > 0 {
+		defaultSinkOptions = append(defaultSinkOptions, WithBlockDataBuffer(undoBufferSize))
+	}
+
+	if config.InfiniteRetry {
+		defaultSinkOptions = append(defaultSinkOptions, WithInfiniteRetry())
+	}
+
+	if config.RetryBackOff != nil {
+		defaultSinkOptions = append(defaultSinkOptions, WithRetryBackOff(config.RetryBackOff.backOff()))
+	}
+
+	if config.LiveBlockTimeDelta > 0 {
+		defaultSinkOptions = append(defaultSinkOptions, WithLivenessChecker(NewDeltaLivenessChecker(time.Duration(config.LiveBlockTimeDelta))))
+	}
+
+	if config.FinalBlocksOnly {
+		defaultSinkOptions = append(defaultSinkOptions, WithFinalBlocksOnly())
+	}
+
+	if resolvedBlockRanges != nil {
+		defaultSinkOptions = append(defaultSinkOptions, WithRequestedBlockRanges(resolvedBlockRanges...))
+	}
+
+	if len(config.Headers) > 0 {
+		defaultSinkOptions = append(defaultSinkOptions, WithExtraHeaders(config.Headers))
+	}
+
+	if authProvider != nil {
+		defaultSinkOptions = append(defaultSinkOptions, WithAuthProvider(authProvider))
+	}
+
+	if config.TLS.isSet() {
+		defaultSinkOptions = append(defaultSinkOptions, WithTLSConfig(config.TLS))
+	}
+
+	if config.GRPCKeepAliveTime > 0 {
+		keepAliveTimeout := time.Duration(config.GRPCKeepAliveTimeout)
+		if keepAliveTimeout == 0 {
+			keepAliveTimeout = defaultKeepAliveTimeout
+		}
+
+		defaultSinkOptions = append(defaultSinkOptions, WithKeepAlive(time.Duration(config.GRPCKeepAliveTime), keepAliveTimeout))
+	}
+
+	if config.StallTimeoutBackprocessing > 0 || config.StallTimeoutLive > 0 {
+		defaultSinkOptions = append(defaultSinkOptions, WithStallWatchdog(time.Duration(config.StallTimeoutBackprocessing), time.Duration(config.StallTimeoutLive)))
+	}
+
+	if len(config.FallbackEndpoints) > 0 {
+		endpoints := []*Endpoint{{Address: config.Endpoint, Insecure: config.Insecure, Plaintext: config.Plaintext}}
+		for _, endpoint := range config.FallbackEndpoints {
+			endpoints = append(endpoints, endpoint.endpoint())
+		}
+
+		defaultSinkOptions = append(defaultSinkOptions, WithEndpoints(endpoints...))
+	}
+
+	if config.EndpointFailoverAfter > 0 || config.EndpointPrimaryCoolDown > 0 {
+		failoverAfter, primaryCoolDown := config.EndpointFailoverAfter, time.Duration(config.EndpointPrimaryCoolDown)
+		if failoverAfter == 0 {
+			failoverAfter = defaultFailoverAfter
+		}
+		if primaryCoolDown == 0 {
+			primaryCoolDown = defaultPrimaryCoolDown
+		}
+
+		defaultSinkOptions = append(defaultSinkOptions, WithEndpointFailover(failoverAfter, primaryCoolDown))
+	}
+
+	if config.PauseDisconnectAfter > 0 {
+		defaultSinkOptions = append(defaultSinkOptions, WithPauseDisconnectAfter(time.Duration(config.PauseDisconnectAfter)))
+	}
+
+	if config.CursorFile != "" {
+		defaultSinkOptions = append(defaultSinkOptions, WithCursorStore(NewFileCursorStore(config.CursorFile)))
+	}
+
+	defaultSinkOptions = append(defaultSinkOptions, WithModuleHashChangePolicy(config.ModuleHashChangePolicy))
+
+	if config.AdminListenAddr != "" {
+		defaultSinkOptions = append(defaultSinkOptions, WithAdminServer(config.AdminListenAddr))
+	}
+
+	if config.DryRun {
+		defaultSinkOptions = append(defaultSinkOptions, WithDryRun(os.Stdout))
+	}
+
+	if config.GRPCMaxRecvMessageSize > 0 {
+		defaultSinkOptions = append(defaultSinkOptions, WithMaxRecvMessageSize(config.GRPCMaxRecvMessageSize))
+	}
+
+	if config.GRPCCompression != "" {
+		defaultSinkOptions = append(defaultSinkOptions, WithCompression(config.GRPCCompression))
+	}
+
+	sinker, err := New(
+		mode,
+		pkg,
+		module,
+		outputModuleHash,
+		clientConfig,
+		zlog,
+		tracer,
+		append(defaultSinkOptions, opts...)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	sinker.config = config.clone()
+	return sinker, nil
+}
+
+// authProvider returns the [AuthProvider] configured by [SinkerConfig.Auth], see [AuthConfig]
+// for the order in which sources are considered, nil if no credentials are configured.
+func (c *SinkerConfig) authProvider() AuthProvider {
+	auth := c.Auth
+	if auth == nil {
+		auth = &AuthConfig{}
+	}
+
+	switch {
+	case auth.TokenFile != "":
+		return NewFileAuthProvider(auth.TokenFile, client.JWT)
+	case len(auth.Command) > 0:
+		return NewCommandAuthProvider(client.JWT, auth.Command[0], auth.Command[1:]...)
+	}
+
+	if apiKey := os.Getenv("SUBSTREAMS_API_KEY"); apiKey != "" {
+		return NewAPIKeyAuthProvider(apiKey, auth.URL)
+	}
+
+	if apiToken := readAPIToken(); apiToken != "" {
+		return NewStaticAuthProvider(apiToken, client.JWT)
+	}
+
+	return nil
+}
+
+// EffectiveConfig returns the [SinkerConfig] reflecting what this sinker instance actually
+// runs with, once defaults, overrides and options have been applied. The block range is
+// rendered with absolute block numbers, a start block relative to the chain's head block
+// being rendered as resolved by the Substreams backend once streamed. It can be dumped with
+// [SinkerConfig.ToYAML] or [SinkerConfig.ToJSON] to be compared across deployments.
+//
+// When the sinker was not created through [NewFromConfig] or [NewFromViper], fields that are
+// unknown to the sinker like the manifest path are left empty, as are the code-only options
+// listed on [SinkerConfig] like a custom [CursorStore].
+//
+// Values of sensitive headers (authorization, API keys, tokens, ...) are redacted, so the
+// returned config is safe to log or dump but cannot be used as-is to create a sinker with the same credentials.
+func (s *Sinker) EffectiveConfig() *SinkerConfig {
+	config := &SinkerConfig{}
+	if s.config != nil {
+		config = s.config.clone()
+	}
+
+	primary := s.endpoints[0]
+	config.Endpoint = primary.Address
+	config.Insecure = primary.Insecure
+	config.Plaintext = primary.Plaintext
+	config.TLS = nil
+	if primary.TLS.isSet() {
+		config.TLS = primary.TLS.clone()
+	} else if s.tlsConfig.isSet() {
+		config.TLS = s.tlsConfig.clone()
+	}
+	config.FallbackEndpoints = nil
+	config.EndpointFailoverAfter, config.EndpointPrimaryCoolDown = 0, 0
+	if len(s.endpoints) > 1 {
+		for _, endpoint := range s.endpoints[1:] {
+			config.FallbackEndpoints = append(config.FallbackEndpoints, &EndpointConfig{
+				Endpoint:  endpoint.Address,
+				Insecure:  endpoint.Insecure,
+				Plaintext: endpoint.Plaintext,
+				TLS:       endpoint.TLS.clone(),
+			})
+		}
+		config.EndpointFailoverAfter, config.EndpointPrimaryCoolDown = s.failoverAfter, Duration(s.primaryCoolDown)
+	}
+	config.Headers = redactHeaders(s.extraHeaders)
+	config.GRPCKeepAliveTime, config.GRPCKeepAliveTimeout = 0, 0
+	if s.keepAlive != nil {
+		config.GRPCKeepAliveTime, config.GRPCKeepAliveTimeout = Duration(s.keepAlive.Time), Duration(s.keepAlive.Timeout)
+	}
+	config.GRPCMaxRecvMessageSize = s.maxRecvMessageSize
+	config.StallTimeoutBackprocessing = Duration(s.stallTimeouts.backprocessing)
+	config.StallTimeoutLive = Duration(s.stallTimeouts.live)
+	config.PauseDisconnectAfter = Duration(s.pauseDisconnectAfter)
+	config.CursorFile = ""
+	if store, ok := s.cursorStore.(*FileCursorStore); ok {
+		config.CursorFile = store.path
+	}
+	config.ModuleHashChangePolicy = s.moduleHashChangePolicy
+	config.AdminListenAddr = s.adminListenAddr
+	config.DryRun = s.dryRunOutput != nil
+	config.GRPCCompression = s.compression
+	config.OutputModule = s.OutputModuleName()
+	config.DevelopmentMode = s.mode == SubstreamsModeDevelopment
+	config.FinalBlocksOnly = s.finalBlocksOnly
+	config.InfiniteRetry = s.infiniteRetry
+
+	// Snapshot of the state updated while streaming, like [Sinker.Status]
+	s.state.mu.Lock()
+	requestedBlockRanges := s.requestedBlockRanges
+	requestedBlockRange, blockRange := s.state.requestedBlockRange, s.state.blockRange
+	config.UndoBufferSize = 0
+	if s.buffer != nil {
+		config.UndoBufferSize = s.buffer.Capacity()
+	}
+	s.state.mu.Unlock()
+
+	config.LiveBlockTimeDelta = 0
+	if checker, ok := s.livenessChecker.(*DeltaLivenessChecker); ok {
+		config.LiveBlockTimeDelta = Duration(checker.delta)
+	}
+
+	config.RetryBackOff = nil
+	if exponential, ok := s.backOff.(*backoff.ExponentialBackOff); ok {
+		config.RetryBackOff = &RetryBackOffConfig{
+			InitialInterval: Duration(exponential.InitialInterval),
+			MaxInterval:     Duration(exponential.MaxInterval),
+			MaxElapsedTime:  Duration(exponential.MaxElapsedTime),
+		}
+	}
+
+	segments := make([]string, len(requestedBlockRanges))
+	for i, segment := range requestedBlockRanges {
+		if segment == requestedBlockRange && blockRange != nil {
+			segment = NewBlockRangeFromRange(blockRange)
+		}
+
+		segments[i] = segment.expression()
+	}
+	config.BlockRange = strings.Join(segments, ",")
+
+	return config
+}
+
+// Duration is a [time.Duration] that is represented as a string like `5m30s` in
+// configuration files.
+type Duration time.Duration
+
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	duration, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+
+	*d = Duration(duration)
+	return nil
+}
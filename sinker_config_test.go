@@ -0,0 +1,205 @@
+package sink
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/streamingfast/bstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadSinkerConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		filename  string
+		content   string
+		want      *SinkerConfig
+		assertion require.ErrorAssertionFunc
+	}{
+		{
+			"yaml defaults kept",
+			"config.yaml",
+			"endpoint: localhost:9000\nmanifest: testdata/substreams.yaml\noutput_module: kv_out\n",
+			&SinkerConfig{Endpoint: "localhost:9000", ManifestPath: "testdata/substreams.yaml", OutputModule: "kv_out", BlockRange: ":", UndoBufferSize: 12, LiveBlockTimeDelta: Duration(300 * time.Second)},
+			require.NoError,
+		},
+		{
+			"yaml full",
+			"config.yml",
+			`
+endpoint: localhost:9000
+plaintext: true
+headers: ["x-a: b"]
+fallback_endpoints:
+  - endpoint: fallback:443
+    tls:
+      ca_file: ca.pem
+endpoint_failover_after: 5
+manifest: testdata/substreams.yaml
+params: ["params_out=a"]
+block_range: "10:20,30:40"
+final_blocks_only: true
+undo_buffer_size: 0
+live_block_time_delta: 1m30s
+pause_disconnect_after: 10m
+cursor_file: cursor.json
+module_hash_change_policy: Warn
+infinite_retry: true
+retry_backoff:
+  initial_interval: 1s
+  max_elapsed_time: 10m
+`,
+			&SinkerConfig{
+				Endpoint:               "localhost:9000",
+				Plaintext:              true,
+				Headers:                []string{"x-a: b"},
+				FallbackEndpoints:      []*EndpointConfig{{Endpoint: "fallback:443", TLS: &TLSConfig{CAFile: "ca.pem"}}},
+				EndpointFailoverAfter:  5,
+				ManifestPath:           "testdata/substreams.yaml",
+				Params:                 []string{"params_out=a"},
+				BlockRange:             "10:20,30:40",
+				FinalBlocksOnly:        true,
+				UndoBufferSize:         0,
+				LiveBlockTimeDelta:     Duration(90 * time.Second),
+				PauseDisconnectAfter:   Duration(10 * time.Minute),
+				CursorFile:             "cursor.json",
+				ModuleHashChangePolicy: ModuleHashChangePolicyWarn,
+				InfiniteRetry:          true,
+				RetryBackOff:           &RetryBackOffConfig{InitialInterval: Duration(time.Second), MaxElapsedTime: Duration(10 * time.Minute)},
+			},
+			require.NoError,
+		},
+		{
+			"json",
+			"config.json",
+			`{"endpoint": "localhost:9000", "live_block_time_delta": "5s"}`,
+			&SinkerConfig{Endpoint: "localhost:9000", BlockRange: ":", UndoBufferSize: 12, LiveBlockTimeDelta: Duration(5 * time.Second)},
+			require.NoError,
+		},
+		{"yaml unknown field", "config.yaml", "endpointt: localhost:9000\n", nil, require.Error},
+		{"json unknown field", "config.json", `{"endpointt": "localhost:9000"}`, nil, require.Error},
+		{"invalid duration", "config.yaml", "live_block_time_delta: 10\n", nil, require.Error},
+		{"invalid module hash change policy", "config.yaml", "module_hash_change_policy: ignore\n", nil, require.Error},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.filename)
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0644))
+
+			got, err := ReadSinkerConfig(path)
+			tt.assertion(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSinkerConfig_Validate(t *testing.T) {
+	config := &SinkerConfig{
+		Endpoint:        "localhost",
+		Insecure:        true,
+		Plaintext:       true,
+		Headers:         []string{"no-colon"},
+		Params:          []string{"=value"},
+		Auth:            &AuthConfig{TokenFile: "token", Command: []string{"helper"}},
+		UndoBufferSize:  -1,
+		GRPCCompression: "brotli",
+
+		FallbackEndpoints:      []*EndpointConfig{{Endpoint: "fallback", Insecure: true}},
+		EndpointFailoverAfter:  -1,
+		PauseDisconnectAfter:   Duration(-time.Second),
+		ModuleHashChangePolicy: ModuleHashChangePolicy(7),
+	}
+
+	assert.EqualError(t, config.Validate(), `endpoint "localhost" must be in the form '<host>:<port>'
+insecure and plaintext are mutually exclusive
+fallback endpoint "fallback" must be in the form '<host>:<port>'
+endpoint failover after and primary cool down must be positive
+grpc compression "brotli" must be one of "gzip" or "zstd"
+header "no-colon" must be in the form 'key: value'
+param "=value" must be in the form '<module>=<value>'
+auth token file and auth command are mutually exclusive
+undo buffer size must be positive, got -1
+pause disconnect after must be positive, got -1s
+module hash change policy ModuleHashChangePolicy(7) must be one of ["Fail" "Warn" "Reset"]`)
+
+	assert.EqualError(t, (&SinkerConfig{}).Validate(), "endpoint is required")
+	assert.NoError(t, (&SinkerConfig{Endpoint: "localhost:9000"}).Validate())
+}
+
+func TestSinkerConfig_authProvider(t *testing.T) {
+	t.Setenv("SUBSTREAMS_API_KEY", "")
+	t.Setenv("SUBSTREAMS_API_TOKEN", "")
+	t.Setenv("SF_API_TOKEN", "")
+
+	assert.Nil(t, (&SinkerConfig{}).authProvider())
+
+	t.Setenv("SUBSTREAMS_API_TOKEN", "token")
+	assert.IsType(t, &StaticAuthProvider{}, (&SinkerConfig{}).authProvider())
+
+	t.Setenv("SUBSTREAMS_API_KEY", "key")
+	assert.Equal(t, "api key exchange (https://auth.example.com)", (&SinkerConfig{Auth: &AuthConfig{URL: "https://auth.example.com"}}).authProvider().(fmt.Stringer).String())
+	assert.Equal(t, "command (helper)", (&SinkerConfig{Auth: &AuthConfig{Command: []string{"helper", "--jwt"}}}).authProvider().(fmt.Stringer).String())
+	assert.Equal(t, "file (token.jwt)", (&SinkerConfig{Auth: &AuthConfig{TokenFile: "token.jwt"}}).authProvider().(fmt.Stringer).String())
+}
+
+func TestNewFromConfig_EffectiveConfig(t *testing.T) {
+	config := NewDefaultSinkerConfig()
+	config.Endpoint = "localhost:9000"
+	config.ManifestPath = "testdata/substreams.yaml"
+	config.OutputModule = "kv_out"
+	config.BlockRange = "+10:+20,100:"
+	config.LiveBlockTimeDelta = Duration(time.Minute)
+	config.Headers = []string{"x-api-key: secret", "x-custom: value"}
+	config.FallbackEndpoints = []*EndpointConfig{{Endpoint: "fallback:9000", Plaintext: true}}
+	config.EndpointPrimaryCoolDown = Duration(time.Minute)
+	config.PauseDisconnectAfter = Duration(time.Hour)
+	config.CursorFile = filepath.Join(t.TempDir(), "cursor.json")
+	config.ModuleHashChangePolicy = ModuleHashChangePolicyReset
+
+	sinker, err := NewFromConfig(context.Background(), config, zlog, nil, WithInfiniteRetry())
+	require.NoError(t, err)
+
+	effective := sinker.EffectiveConfig()
+	assert.Equal(t, "10:30,100:", effective.BlockRange)
+	assert.Equal(t, "kv_out", effective.OutputModule)
+	assert.Equal(t, []string{"x-api-key: <redacted>", "x-custom: value"}, effective.Headers)
+	assert.Equal(t, 12, effective.UndoBufferSize)
+	assert.Equal(t, Duration(time.Minute), effective.LiveBlockTimeDelta)
+	assert.True(t, effective.InfiniteRetry)
+	assert.NotNil(t, effective.RetryBackOff)
+	assert.Equal(t, []*EndpointConfig{{Endpoint: "fallback:9000", Plaintext: true}}, effective.FallbackEndpoints)
+	assert.Equal(t, 3, effective.EndpointFailoverAfter)
+	assert.Equal(t, Duration(time.Minute), effective.EndpointPrimaryCoolDown)
+	assert.Equal(t, Duration(time.Hour), effective.PauseDisconnectAfter)
+	assert.Equal(t, config.CursorFile, effective.CursorFile)
+	assert.Equal(t, ModuleHashChangePolicyReset, effective.ModuleHashChangePolicy)
+
+	content, err := effective.ToYAML()
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "effective.yaml")
+	require.NoError(t, os.WriteFile(path, content, 0644))
+
+	reread, err := ReadSinkerConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, effective, reread)
+
+	_, err = NewFromConfig(context.Background(), &SinkerConfig{}, zlog, nil)
+	assert.EqualError(t, err, "invalid config: endpoint is required")
+}
+
+func TestSinker_EffectiveConfig_HeadRelative(t *testing.T) {
+	end := uint64(200)
+	sinker := newTestSinker(t, WithRequestedBlockRanges(NewBlockRange(100, &end), NewBlockRange(-1000, nil)))
+	assert.Equal(t, "100:200,-1000:", sinker.EffectiveConfig().BlockRange)
+
+	// Once streamed, the start block relative to chain's head block is resolved by the backend
+	sinker.activateBlockRange(sinker.RequestedBlockRanges()[1])
+	sinker.state.update(func(state *runtimeState) { state.blockRange = bstream.NewOpenRange(5000) })
+	assert.Equal(t, "100:200,5000:", sinker.EffectiveConfig().BlockRange)
+}
@@ -0,0 +1,148 @@
+package sink
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// FlagEnvAnnotation is the [pflag.Flag] annotation holding the name of the environment
+// variable a flag is bound to, see [BindFlagsEnv].
+const FlagEnvAnnotation = "substreams-sink/env"
+
+// flagEnvPrefixAnnotation holds the prefix itself so that positional arguments of
+// [NewFromViper] can be bound using the same prefix.
+const flagEnvPrefixAnnotation = "substreams-sink/env-prefix"
+
+// Names used to bind positional arguments of [NewFromViper] to environment variables, see [BindFlagsEnv].
+const (
+	EnvArgEndpoint     = "endpoint"
+	EnvArgManifest     = "manifest"
+	EnvArgOutputModule = "output-module"
+	EnvArgBlockRange   = "block-range"
+)
+
+// BindFlagsEnv binds every flag added by [AddFlagsToSet] to `flags` to an environment variable
+// named `<prefix>_<FLAG_NAME>`, the flag name being upper cased and its dashes replaced by
+// underscores. For example, with prefix `SINK`, flag `--undo-buffer-size` is bound to
+// `SINK_UNDO_BUFFER_SIZE` and flag `--header` to `SINK_HEADER`. It must be called after
+// [AddFlagsToSet], flags ignored through [FlagIgnore] are not bound.
+//
+// Flags accepting multiple values (`--params` and `--header`) accept multiple values in their
+// environment variable separated by a new line.
+//
+// The positional arguments of [NewFromViper] are bound too when they are passed as an empty
+// string, as `<prefix>_ENDPOINT`, `<prefix>_MANIFEST`, `<prefix>_OUTPUT_MODULE` and
+// `<prefix>_BLOCK_RANGE`, see the `EnvArg*` constants.
+//
+// Environment variables are applied by [NewFromViper], the precedence is:
+//
+//   - The flag explicitly provided on the command line (or the non-empty positional argument)
+//   - The environment variable bound by [BindFlagsEnv]
+//   - The value coming from `cli.ConfigureViper` (its own environment variables or config file)
+//   - The flag's default value
+func BindFlagsEnv(flags *pflag.FlagSet, prefix string) {
+	for _, name := range []string{
+		FlagParams, FlagNetwork, FlagInsecure, FlagPlaintext, FlagUndoBufferSize, FlagLiveBlockTimeDelta,
+		FlagDevelopmentMode, FlagFinalBlocksOnly, FlagInfiniteRetry, FlagSkipPackageValidation, FlagExtraHeaders,
+		FlagAuthTokenFile, FlagAuthCommand, FlagAuthURL, FlagTLSCAFile, FlagTLSCertFile, FlagTLSKeyFile, FlagTLSServerName,
+		FlagGRPCKeepAliveTime, FlagGRPCKeepAliveTimeout, FlagGRPCMaxRecvMsgSize, FlagGRPCCompression,
+		FlagStallTimeoutBackproc, FlagStallTimeoutLive, FlagAdminListenAddr, FlagDryRun,
+	} {
+		flag := flags.Lookup(name)
+		if flag == nil || flag.Deprecated != "" {
+			continue
+		}
+
+		envName := flagEnvName(prefix, name)
+		setAnnotation(flag, FlagEnvAnnotation, envName)
+		setAnnotation(flag, flagEnvPrefixAnnotation, prefix)
+		flag.Usage += fmt.Sprintf(" (env: %s)", envName)
+	}
+}
+
+// applyFlagsEnv sets the value of every flag bound to an environment variable through
+// [BindFlagsEnv] that was not explicitly provided on the command line.
+func applyFlagsEnv(cmd *cobra.Command) error {
+	var errs []error
+
+	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		envName := getAnnotation(flag, FlagEnvAnnotation)
+		if envName == "" || flag.Changed {
+			return
+		}
+
+		value, found := os.LookupEnv(envName)
+		if !found {
+			return
+		}
+
+		values := []string{value}
+		if strings.HasSuffix(flag.Value.Type(), "Array") || strings.HasSuffix(flag.Value.Type(), "Slice") {
+			values = strings.Split(value, "\n")
+		}
+
+		for _, value := range values {
+			if err := cmd.Flags().Set(flag.Name, value); err != nil {
+				errs = append(errs, fmt.Errorf("environment variable %s: %w", envName, err))
+				return
+			}
+		}
+	})
+
+	return errors.Join(errs...)
+}
+
+// envArg returns `value` if non-empty, otherwise the value of the environment variable
+// bound to `name` if [BindFlagsEnv] was used on `cmd`'s flags.
+func envArg(cmd *cobra.Command, name string, value string) string {
+	if value != "" {
+		return value
+	}
+
+	prefix, found := flagsEnvPrefix(cmd)
+	if !found {
+		return value
+	}
+
+	return os.Getenv(flagEnvName(prefix, name))
+}
+
+func flagsEnvPrefix(cmd *cobra.Command) (prefix string, found bool) {
+	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		if values, ok := flag.Annotations[flagEnvPrefixAnnotation]; ok && !found {
+			prefix, found = values[0], true
+		}
+	})
+
+	return
+}
+
+func flagEnvName(prefix string, name string) string {
+	envName := strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+	if prefix == "" {
+		return envName
+	}
+
+	return strings.ToUpper(prefix) + "_" + envName
+}
+
+func setAnnotation(flag *pflag.Flag, key string, value string) {
+	if flag.Annotations == nil {
+		flag.Annotations = map[string][]string{}
+	}
+
+	flag.Annotations[key] = []string{value}
+}
+
+func getAnnotation(flag *pflag.Flag, key string) string {
+	if values := flag.Annotations[key]; len(values) > 0 {
+		return values[0]
+	}
+
+	return ""
+}
@@ -0,0 +1,154 @@
+package sink
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBindFlagsEnv(t *testing.T) {
+	cmd := &cobra.Command{Use: "run"}
+	AddFlagsToSet(cmd.Flags())
+	BindFlagsEnv(cmd.Flags(), "sink")
+
+	tests := []struct {
+		flag     string
+		expected string
+	}{
+		{FlagUndoBufferSize, "SINK_UNDO_BUFFER_SIZE"},
+		{FlagExtraHeaders, "SINK_HEADER"},
+		{FlagLiveBlockTimeDelta, "SINK_LIVE_BLOCK_TIME_DELTA"},
+		{FlagIrreversibleOnly, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.flag, func(t *testing.T) {
+			flag := cmd.Flags().Lookup(tt.flag)
+			require.NotNil(t, flag)
+
+			assert.Equal(t, tt.expected, getAnnotation(flag, FlagEnvAnnotation))
+			if tt.expected != "" {
+				assert.Contains(t, flag.Usage, "(env: "+tt.expected+")")
+			}
+		})
+	}
+}
+
+func TestBindFlagsEnv_IgnoredFlags(t *testing.T) {
+	cmd := &cobra.Command{Use: "run"}
+	AddFlagsToSet(cmd.Flags(), FlagIgnore(FlagInsecure))
+	BindFlagsEnv(cmd.Flags(), "SINK")
+
+	assert.Nil(t, cmd.Flags().Lookup(FlagInsecure))
+	assert.Equal(t, "SINK_PLAINTEXT", getAnnotation(cmd.Flags().Lookup(FlagPlaintext), FlagEnvAnnotation))
+}
+
+func Test_applyFlagsEnv(t *testing.T) {
+	tests := []struct {
+		name      string
+		env       map[string]string
+		args      []string
+		assertion func(t *testing.T, cmd *cobra.Command)
+		expectErr string
+	}{
+		{
+			"defaults without env",
+			nil,
+			nil,
+			func(t *testing.T, cmd *cobra.Command) {
+				params, network, undoBufferSize, liveBlockTimeDelta, _, _, finalBlocksOnly, _, headers, _, _ := getViperFlags(cmd)
+				assert.Empty(t, params)
+				assert.Equal(t, "", network)
+				assert.Equal(t, 12, undoBufferSize)
+				assert.Equal(t, 300*time.Second, liveBlockTimeDelta)
+				assert.False(t, finalBlocksOnly)
+				assert.Empty(t, headers)
+			},
+			"",
+		},
+		{
+			"env overrides defaults",
+			map[string]string{
+				"SINK_UNDO_BUFFER_SIZE":      "24",
+				"SINK_LIVE_BLOCK_TIME_DELTA": "1m",
+				"SINK_FINAL_BLOCKS_ONLY":     "true",
+				"SINK_HEADER":                "X-First: 1\nX-Second: 2",
+				"SINK_NETWORK":               "sepolia",
+			},
+			nil,
+			func(t *testing.T, cmd *cobra.Command) {
+				_, network, undoBufferSize, liveBlockTimeDelta, _, _, finalBlocksOnly, _, headers, _, _ := getViperFlags(cmd)
+				assert.Equal(t, "sepolia", network)
+				assert.Equal(t, 24, undoBufferSize)
+				assert.Equal(t, time.Minute, liveBlockTimeDelta)
+				assert.True(t, finalBlocksOnly)
+				assert.Equal(t, []string{"X-First: 1", "X-Second: 2"}, headers)
+			},
+			"",
+		},
+		{
+			"command line overrides env",
+			map[string]string{
+				"SINK_UNDO_BUFFER_SIZE": "24",
+				"SINK_HEADER":           "X-Env: 1",
+			},
+			[]string{"--undo-buffer-size=48", "-H", "X-Cli: 1"},
+			func(t *testing.T, cmd *cobra.Command) {
+				_, _, undoBufferSize, _, _, _, _, _, headers, _, _ := getViperFlags(cmd)
+				assert.Equal(t, 48, undoBufferSize)
+				assert.Equal(t, []string{"X-Cli: 1"}, headers)
+			},
+			"",
+		},
+		{
+			"invalid env value",
+			map[string]string{
+				"SINK_UNDO_BUFFER_SIZE": "many",
+			},
+			nil,
+			nil,
+			`environment variable SINK_UNDO_BUFFER_SIZE: invalid argument "many" for "--undo-buffer-size" flag: strconv.ParseInt: parsing "many": invalid syntax`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			cmd := &cobra.Command{Use: "run"}
+			AddFlagsToSet(cmd.Flags())
+			BindFlagsEnv(cmd.Flags(), "SINK")
+			require.NoError(t, cmd.Flags().Parse(tt.args))
+
+			err := applyFlagsEnv(cmd)
+			if tt.expectErr != "" {
+				require.EqualError(t, err, tt.expectErr)
+				return
+			}
+
+			require.NoError(t, err)
+			tt.assertion(t, cmd)
+		})
+	}
+}
+
+func Test_envArg(t *testing.T) {
+	t.Setenv("SINK_ENDPOINT", "mainnet.eth.streamingfast.io:443")
+
+	bound := &cobra.Command{Use: "run"}
+	AddFlagsToSet(bound.Flags())
+	BindFlagsEnv(bound.Flags(), "SINK")
+
+	unbound := &cobra.Command{Use: "run"}
+	AddFlagsToSet(unbound.Flags())
+
+	assert.Equal(t, "mainnet.eth.streamingfast.io:443", envArg(bound, EnvArgEndpoint, ""))
+	assert.Equal(t, "localhost:9000", envArg(bound, EnvArgEndpoint, "localhost:9000"))
+	assert.Equal(t, "", envArg(bound, EnvArgManifest, ""))
+	assert.Equal(t, "", envArg(unbound, EnvArgEndpoint, ""))
+}
@@ -1,8 +1,13 @@
 package sink
 
 import (
+	"io"
+	"time"
+
 	"github.com/cenkalti/backoff/v4"
 	"github.com/streamingfast/bstream"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"google.golang.org/grpc/keepalive"
 )
 
 type Option func(s *Sinker)
@@ -66,19 +71,231 @@ func WithLivenessChecker(livenessChecker LivenessChecker) Option {
 	}
 }
 
+// WithLivenessTransitionCallback configures a callback called each time the liveness state
+// determined by the configured [LivenessChecker] changes, before the block that changed it is
+// handled. The initial state is not live, so the callback is called with `isLive` true on the
+// first live block. It has no effect without a [LivenessChecker], see [WithLivenessChecker].
+func WithLivenessTransitionCallback(callback func(isLive bool, block *pbsubstreams.Clock)) Option {
+	return func(s *Sinker) {
+		s.livenessCallback = callback
+	}
+}
+
 // WithBlockRange configures the [Sinker] instance to only stream for the range specified. If
 // there is no range specified on the [Sinker], the [Sinker] is going to sink automatically
 // from module's start block to live never ending.
 func WithBlockRange(blockRange *bstream.Range) Option {
 	return func(s *Sinker) {
-		s.blockRange = blockRange
+		s.requestedBlockRanges = nil
+		if blockRange != nil {
+			s.requestedBlockRanges = BlockRanges{NewBlockRangeFromRange(blockRange)}
+		}
+	}
+}
+
+// WithRequestedBlockRanges acts like [WithBlockRange] but accepts one or more [BlockRange]
+// segments, as returned by [ReadBlockRanges], which are streamed sequentially. The start
+// block of the last segment can be relative to the chain's head block.
+//
+// Segments must be ordered, non-overlapping and only the last one can be open ended,
+// [New] returns an error otherwise.
+func WithRequestedBlockRanges(blockRanges ...*BlockRange) Option {
+	return func(s *Sinker) {
+		s.requestedBlockRanges = blockRanges
 	}
 }
 
 // WithExtraHeaders configures the [Sinker] instance to send extra headers to the Substreams
-// backend server.
+// backend server. Each header is in the form `key: value`, only the first colon separates the
+// key from the value so the value can contain colons.
+//
+// The value can be sourced from an environment variable with `key: @env:NAME` or from a file
+// with `key: @file:/path/to/value`, the file's content being trimmed. A value starting with
+// `@@` is sent literally without its first `@`. Sourced values are resolved each time the
+// [Sinker] starts streaming.
+//
+// [New] returns an error if a header is invalid or if its value cannot be resolved.
 func WithExtraHeaders(headers []string) Option {
 	return func(s *Sinker) {
 		s.extraHeaders = headers
 	}
 }
+
+// WithAuthProvider configures the [Sinker] to authenticate against the Substreams endpoint
+// using `provider`, which is consulted each time the [Sinker] connects to the endpoint. The
+// credentials of the [client.SubstreamsClientConfig] received by [New] are then ignored.
+//
+// When the endpoint rejects the credentials with an `Unauthenticated` error, credentials are
+// refreshed through [AuthProvider.Refresh] and the request is retried once before failing.
+func WithAuthProvider(provider AuthProvider) Option {
+	return func(s *Sinker) {
+		s.authProvider = provider
+	}
+}
+
+// WithEndpoints configures the [Sinker] to stream from an ordered list of endpoints instead of
+// the single endpoint of the [client.SubstreamsClientConfig] received by [New], the first one
+// being the primary endpoint. Each endpoint has its own TLS, plaintext and auth settings, an
+// endpoint without [Endpoint.AuthProvider] uses the [Sinker]'s credentials.
+//
+// When the active endpoint fails repeatedly with retryable errors, the [Sinker] switches to the
+// next endpoint and resumes from its last cursor, cursors being portable across providers of the
+// same chain. See [WithEndpointFailover] to configure the failover policy.
+func WithEndpoints(endpoints ...*Endpoint) Option {
+	return func(s *Sinker) {
+		s.endpoints = endpoints
+	}
+}
+
+// WithEndpointFailover configures the failover policy used when multiple endpoints are configured
+// through [WithEndpoints]. The [Sinker] switches to the next endpoint after `failoverAfter`
+// consecutive retryable failures (defaults to 3) and switches back to the primary endpoint once
+// `primaryCoolDown` elapsed since it was left (defaults to 5m), the switch back happens between
+// two messages so the handler is never interrupted.
+func WithEndpointFailover(failoverAfter int, primaryCoolDown time.Duration) Option {
+	return func(s *Sinker) {
+		s.failoverAfter = failoverAfter
+		s.primaryCoolDown = primaryCoolDown
+	}
+}
+
+// WithTLSConfig customizes the TLS settings used to connect to the Substreams endpoint, for
+// example a custom CA bundle, a client certificate for mutual TLS or a server name override.
+// It applies to every endpoint configured through [WithEndpoints] that has no TLS settings of
+// its own and is ignored for plaintext endpoints.
+func WithTLSConfig(config *TLSConfig) Option {
+	return func(s *Sinker) {
+		s.tlsConfig = config
+	}
+}
+
+// WithKeepAlive configures the gRPC keepalive pings sent to the Substreams endpoint, a ping is
+// sent after `interval` without activity and the connection is considered dead if it's not
+// acknowledged within `timeout`. Pings are also sent while no stream is active, which keeps
+// idle connections behind NAT or load balancers alive.
+//
+// Ensure the Substreams endpoint accepts pings this often, it closes the connection otherwise.
+func WithKeepAlive(interval time.Duration, timeout time.Duration) Option {
+	return func(s *Sinker) {
+		s.keepAlive = &keepalive.ClientParameters{
+			Time:                interval,
+			Timeout:             timeout,
+			PermitWithoutStream: true,
+		}
+	}
+}
+
+// WithMaxRecvMessageSize configures the maximum size in bytes of a message received from the
+// Substreams endpoint, use it when map outputs are larger than the default limit.
+func WithMaxRecvMessageSize(size int) Option {
+	return func(s *Sinker) {
+		s.maxRecvMessageSize = size
+	}
+}
+
+// WithCompression configures the compressor used for the Substreams stream, [CompressionGzip]
+// or [CompressionZstd], the Substreams endpoint then compresses the messages it sends with the
+// same compressor if it supports it. Compressed bytes received are reported by the
+// [MessageWireSizeBytes] metric while [MessageSizeBytes] reports decoded bytes.
+//
+// Using [CompressionZstd] registers a zstd compressor in gRPC's compressors registry, which is
+// global to the process, unless one is already registered. Decompressed zstd messages are
+// capped to 256 MiB.
+func WithCompression(compressor string) Option {
+	if compressor == CompressionZstd {
+		registerZstdCompressor()
+	}
+
+	return func(s *Sinker) {
+		s.compression = compressor
+	}
+}
+
+// WithStallWatchdog cancels the Substreams stream and reconnects through the usual retry logic
+// when no message of any kind (data, progress or undo) is received within a timeout, guarding
+// against streams that silently stop sending messages. The `backprocessing` timeout applies
+// until the first data message of a stream is received, the `live` timeout after, a zero
+// timeout disables the watchdog in the corresponding mode. The time spent by the handler
+// processing a message is never counted. Reconnections are counted by the
+// [StallWatchdogReconnectCount] metric.
+func WithStallWatchdog(backprocessing time.Duration, live time.Duration) Option {
+	return func(s *Sinker) {
+		s.stallTimeouts = stallTimeouts{backprocessing: backprocessing, live: live}
+	}
+}
+
+// WithPauseDisconnectAfter closes the Substreams stream when the [Sinker] stays paused longer
+// than `gracePeriod`, see [Sinker.Pause]. By default the stream stays open while paused, which
+// can lead the Substreams endpoint to close it on its own after a while.
+func WithPauseDisconnectAfter(gracePeriod time.Duration) Option {
+	return func(s *Sinker) {
+		s.pauseDisconnectAfter = gracePeriod
+	}
+}
+
+// WithAdminServer serves the admin API on `listenAddr` (e.g. `localhost:9102`) while the
+// [Sinker] runs, see [Sinker.AdminHandler] for the available routes. The API has no
+// authentication, bind it to a private interface.
+func WithAdminServer(listenAddr string) Option {
+	return func(s *Sinker) {
+		s.adminListenAddr = listenAddr
+	}
+}
+
+// WithGracefulStopCallback configures a callback called with the cursor of the last block
+// handled when the [Sinker] stops with [Sinker.StopGracefully], once the handler has been
+// flushed, see [SinkerFlushHandler]. It's the cursor to persist to restart from.
+func WithGracefulStopCallback(callback func(cursor *Cursor)) Option {
+	return func(s *Sinker) {
+		s.gracefulStopCallback = callback
+	}
+}
+
+// WithCursorStore configures the [Sinker] to save the cursor of the last block handled, along
+// with the output module hash, to `store` when it stops gracefully, once
+// [SinkerFlushHandler.HandleFlush] returned, and when the block range completes. When the
+// cursor given to [Sinker.Run] is blank, the [Sinker] starts from the cursor loaded from `store`.
+//
+// At startup, the stored output module hash is compared with the current one and the policy
+// configured with [WithModuleHashChangePolicy] is applied if it changed.
+func WithCursorStore(store CursorStore) Option {
+	return func(s *Sinker) {
+		s.cursorStore = store
+	}
+}
+
+// WithStoredModuleHash configures the output module hash that produced the cursor given to
+// [Sinker.Run], for handlers persisting the cursor on their own along with
+// [Sinker.OutputModuleHash]. At startup, it's compared with the current output module hash
+// and the policy configured with [WithModuleHashChangePolicy] is applied if it changed. An
+// empty hash disables the comparison.
+func WithStoredModuleHash(hash string) Option {
+	return func(s *Sinker) {
+		s.storedModuleHash = hash
+	}
+}
+
+// WithModuleHashChangePolicy configures the action taken when the output module hash changed
+// since the stored cursor was produced, see [WithCursorStore] and [WithStoredModuleHash]:
+// [ModuleHashChangePolicyFail] (the default) terminates the [Sinker] with a
+// [ModuleHashChangedError], [ModuleHashChangePolicyWarn] logs a warning and continues from the
+// stored cursor and [ModuleHashChangePolicyReset] discards the stored cursor and restarts from
+// the output module's initial block, the first requested block range segment then starting at
+// it. The handler is notified of the change before the policy is applied if it implements
+// [SinkerModuleHashChangeHandler].
+func WithModuleHashChangePolicy(policy ModuleHashChangePolicy) Option {
+	return func(s *Sinker) {
+		s.moduleHashChangePolicy = policy
+	}
+}
+
+// WithDryRun streams the requested block range without calling the handler given to
+// [Sinker.Run], one JSON line is written to `out` instead for each message received. A block
+// line holds the block's clock, cursor, the output size in bytes and the output decoded to
+// JSON using the Protobuf descriptors embedded in the package, an undo line holds the last
+// valid block. No cursor is saved to the [CursorStore] in this mode, see [WithCursorStore].
+func WithDryRun(out io.Writer) Option {
+	return func(s *Sinker) {
+		s.dryRunOutput = out
+	}
+}
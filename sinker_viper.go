@@ -1,8 +1,10 @@
 package sink
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -13,7 +15,6 @@ import (
 	"github.com/streamingfast/bstream"
 	"github.com/streamingfast/cli/sflags"
 	"github.com/streamingfast/logging"
-	"github.com/streamingfast/substreams/client"
 	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
 	"go.uber.org/zap"
 )
@@ -31,6 +32,21 @@ const (
 	FlagIrreversibleOnly      = "irreversible-only"
 	FlagSkipPackageValidation = "skip-package-validation"
 	FlagExtraHeaders          = "header"
+	FlagAuthTokenFile         = "auth-token-file"
+	FlagAuthCommand           = "auth-command"
+	FlagAuthURL               = "auth-url"
+	FlagTLSCAFile             = "tls-ca-file"
+	FlagTLSCertFile           = "tls-cert-file"
+	FlagTLSKeyFile            = "tls-key-file"
+	FlagTLSServerName         = "tls-server-name"
+	FlagGRPCKeepAliveTime     = "grpc-keepalive-time"
+	FlagGRPCKeepAliveTimeout  = "grpc-keepalive-timeout"
+	FlagGRPCMaxRecvMsgSize    = "grpc-max-recv-msg-size"
+	FlagGRPCCompression       = "grpc-compression"
+	FlagStallTimeoutBackproc  = "stall-timeout-backprocessing"
+	FlagStallTimeoutLive      = "stall-timeout-live"
+	FlagAdminListenAddr       = "admin-listen-addr"
+	FlagDryRun                = "dry-run"
 )
 
 func FlagIgnore(in ...string) FlagIgnored {
@@ -63,12 +79,32 @@ func (i flagIgnoredList) IsIgnored(flag string) bool {
 //	Flag `--infinite-retry` (defaults `false`)
 //	Flag `--skip-package-validation` (defaults `false`)
 //	Flag `--header (-H)` (defaults `[]`)
+//	Flag `--auth-token-file` (defaults `""`)
+//	Flag `--auth-command` (defaults `""`)
+//	Flag `--auth-url` (defaults `""`)
+//	Flag `--tls-ca-file` (defaults `""`)
+//	Flag `--tls-cert-file` (defaults `""`)
+//	Flag `--tls-key-file` (defaults `""`)
+//	Flag `--tls-server-name` (defaults `""`)
+//	Flag `--grpc-keepalive-time` (defaults `0`)
+//	Flag `--grpc-keepalive-timeout` (defaults `20s`)
+//	Flag `--grpc-max-recv-msg-size` (defaults `0`)
+//	Flag `--grpc-compression` (defaults `""`)
+//	Flag `--stall-timeout-backprocessing` (defaults `0`)
+//	Flag `--stall-timeout-live` (defaults `0`)
+//	Flag `--admin-listen-addr` (defaults `""`)
+//	Flag `--dry-run` (defaults `false`)
 //
 // The `ignore` field can be used to multiple times to avoid adding the specified
 // `flags` to the the set. This can be used for example to avoid adding `--final-blocks-only`
 // when the sink is always final only.
 //
 //	AddFlagsToSet(flags, sink.FlagIgnore(sink.FlagFinalBlocksOnly))
+//
+// Use [BindFlagsEnv] afterward to bind every added flag to an environment variable:
+//
+//	AddFlagsToSet(flags)
+//	BindFlagsEnv(flags, "SINK")
 func AddFlagsToSet(flags *pflag.FlagSet, ignore ...FlagIgnored) {
 	flagIncluded := func(x string) bool { return every(ignore, func(e FlagIgnored) bool { return !e.IsIgnored(x) }) }
 
@@ -119,7 +155,67 @@ func AddFlagsToSet(flags *pflag.FlagSet, ignore ...FlagIgnored) {
 	}
 
 	if flagIncluded(FlagExtraHeaders) {
-		flags.StringArrayP(FlagExtraHeaders, "H", nil, "Additional headers to be sent in the substreams request")
+		flags.StringArrayP(FlagExtraHeaders, "H", nil, "Additional headers to be sent in the substreams request in the form 'key: value', the value can be read from an environment variable with 'key: @env:NAME' or from a file with 'key: @file:<path>'")
+	}
+
+	if flagIncluded(FlagAuthTokenFile) {
+		flags.String(FlagAuthTokenFile, "", "Read the JWT from this file instead of SUBSTREAMS_API_TOKEN environment variable, the file is read again when it changes")
+	}
+
+	if flagIncluded(FlagAuthCommand) {
+		flags.String(FlagAuthCommand, "", "Run this credential helper command (split on whitespaces) to obtain the JWT from its standard output, run again on each reconnection")
+	}
+
+	if flagIncluded(FlagAuthURL) {
+		flags.String(FlagAuthURL, "", fmt.Sprintf("Auth service used to exchange the API key of SUBSTREAMS_API_KEY environment variable for a JWT (defaults to %s)", DefaultAuthURL))
+	}
+
+	if flagIncluded(FlagTLSCAFile) {
+		flags.String(FlagTLSCAFile, "", "PEM bundle of the CAs used to verify the server certificate instead of the system's CAs")
+	}
+
+	if flagIncluded(FlagTLSCertFile) {
+		flags.String(FlagTLSCertFile, "", "PEM client certificate presented to the server for mutual TLS, requires --tls-key-file")
+	}
+
+	if flagIncluded(FlagTLSKeyFile) {
+		flags.String(FlagTLSKeyFile, "", "PEM private key of the client certificate given by --tls-cert-file")
+	}
+
+	if flagIncluded(FlagTLSServerName) {
+		flags.String(FlagTLSServerName, "", "Override the server name used to verify the server certificate")
+	}
+
+	if flagIncluded(FlagGRPCKeepAliveTime) {
+		flags.Duration(FlagGRPCKeepAliveTime, 0, "Send a keepalive ping to the Substreams endpoint after this inactivity, even without active stream, keeps idle connections behind NAT alive (0 keeps gRPC client default)")
+	}
+
+	if flagIncluded(FlagGRPCKeepAliveTimeout) {
+		flags.Duration(FlagGRPCKeepAliveTimeout, defaultKeepAliveTimeout, "Consider the connection dead if a keepalive ping is not acknowledged within this delay, used only with --grpc-keepalive-time")
+	}
+
+	if flagIncluded(FlagGRPCMaxRecvMsgSize) {
+		flags.Int(FlagGRPCMaxRecvMsgSize, 0, "Maximum size in bytes of a message received from the Substreams endpoint (0 keeps gRPC client default)")
+	}
+
+	if flagIncluded(FlagGRPCCompression) {
+		flags.String(FlagGRPCCompression, "", fmt.Sprintf("Compress the Substreams stream with this compressor, one of %q or %q", CompressionGzip, CompressionZstd))
+	}
+
+	if flagIncluded(FlagStallTimeoutBackproc) {
+		flags.Duration(FlagStallTimeoutBackproc, 0, "Cancel and retry the Substreams stream if no message (data, progress or undo) is received within this delay before the first data message, 0 disables it")
+	}
+
+	if flagIncluded(FlagStallTimeoutLive) {
+		flags.Duration(FlagStallTimeoutLive, 0, "Cancel and retry the Substreams stream if no message (data, progress or undo) is received within this delay after the first data message, 0 disables it")
+	}
+
+	if flagIncluded(FlagAdminListenAddr) {
+		flags.String(FlagAdminListenAddr, "", "Serve the admin HTTP API (status, config, pause, resume, reconnect, stop at block) on this address, e.g. 'localhost:9102', it has no authentication so bind it to a private interface (disabled when empty)")
+	}
+
+	if flagIncluded(FlagDryRun) {
+		flags.Bool(FlagDryRun, false, "Stream the block range without calling the sink's handler, printing instead one JSON line per block with the decoded module output and its size, and per undo signal")
 	}
 }
 
@@ -132,10 +228,17 @@ func AddFlagsToSet(flags *pflag.FlagSet, ignore ...FlagIgnored) {
 // The `expectedOutputModuleType` should be the fully qualified expected Protobuf
 // package.
 //
+// The `blockRange` accepts the syntax of [ReadBlockRangesWithTimeResolver], date and time
+// boundaries are resolved against `endpoint` using a [SubstreamsBlockTimeResolver].
+//
 // The `manifestPath` can be left empty in which case we this method is going to look
 // in the current directory for a `substreams.yaml` file. If the `manifestPath` is
 // non-empty and points to a directory, we will look for a `substreams.yaml` file in that
 // directory.
+//
+// When the flags were bound with [BindFlagsEnv], flags not provided on the command line are
+// read from their environment variable, as well as the empty positional arguments, see
+// [BindFlagsEnv] for the precedence rules.
 func NewFromViper(
 	cmd *cobra.Command,
 	expectedOutputModuleType string,
@@ -144,95 +247,69 @@ func NewFromViper(
 	tracer logging.Tracer,
 	opts ...Option,
 ) (*Sinker, error) {
-	params, network, undoBufferSize, liveBlockTimeDelta, isDevelopmentMode, infiniteRetry, finalBlocksOnly, skipPackageValidation, extraHeaders := getViperFlags(cmd)
-
-	zlog.Info("sinker from CLI",
-		zap.String("endpoint", endpoint),
-		zap.String("manifest_path", manifestPath),
-		zap.Strings("params", params),
-		zap.String("network", network),
-		zap.String("output_module_name", outputModuleName),
-		zap.Stringer("expected_module_type", expectedModuleType(expectedOutputModuleType)),
-		zap.String("block_range", blockRange),
-		zap.Bool("development_mode", isDevelopmentMode),
-		zap.Bool("infinite_retry", infiniteRetry),
-		zap.Bool("final_blocks_only", finalBlocksOnly),
-		zap.Bool("skip_package_validation", skipPackageValidation),
-		zap.Duration("live_block_time_delta", liveBlockTimeDelta),
-		zap.Int("undo_buffer_size", undoBufferSize),
-		zap.Strings("extra_headers", extraHeaders),
-	)
-
-	pkg, module, outputModuleHash, resolvedBlockRange, err := ReadManifestAndModuleAndBlockRange(
-		manifestPath,
-		network,
-		params,
-		outputModuleName,
-		expectedOutputModuleType,
-		skipPackageValidation,
-		blockRange,
-		zlog,
-	)
-	if err != nil {
-		return nil, fmt.Errorf("reading manifest: %w", err)
+	if err := applyFlagsEnv(cmd); err != nil {
+		return nil, fmt.Errorf("read flags from environment: %w", err)
 	}
 
-	apiToken := readAPIToken()
-	zlog.Debug("resolved block range", zap.Stringer("range", resolvedBlockRange))
+	endpoint = envArg(cmd, EnvArgEndpoint, endpoint)
+	manifestPath = envArg(cmd, EnvArgManifest, manifestPath)
+	outputModuleName = envArg(cmd, EnvArgOutputModule, outputModuleName)
+	blockRange = envArg(cmd, EnvArgBlockRange, blockRange)
 
-	if finalBlocksOnly {
-		zlog.Debug("override undo buffer size to 0 since final blocks only is requested")
-		undoBufferSize = 0
+	config := &SinkerConfig{
+		Endpoint:                 endpoint,
+		ManifestPath:             manifestPath,
+		OutputModule:             outputModuleName,
+		ExpectedOutputModuleType: expectedOutputModuleType,
+		BlockRange:               blockRange,
 	}
 
-	clientConfig := client.NewSubstreamsClientConfig(
-		endpoint,
-		apiToken,
-		client.JWT,
-		sflags.MustGetBool(cmd, FlagInsecure),
-		sflags.MustGetBool(cmd, FlagPlaintext),
-	)
+	var liveBlockTimeDelta time.Duration
+	config.Params, config.Network, config.UndoBufferSize, liveBlockTimeDelta, config.DevelopmentMode, config.InfiniteRetry, config.FinalBlocksOnly, config.SkipPackageValidation, config.Headers, config.Insecure, config.Plaintext = getViperFlags(cmd)
+	config.LiveBlockTimeDelta = Duration(liveBlockTimeDelta)
+	config.Auth = getViperAuthFlags(cmd)
+	config.TLS = getViperTLSFlags(cmd)
+	getViperGRPCFlags(cmd, config)
 
-	mode := SubstreamsModeProduction
-	if isDevelopmentMode {
-		mode = SubstreamsModeDevelopment
+	if sflags.FlagDefined(cmd, FlagStallTimeoutBackproc) {
+		config.StallTimeoutBackprocessing = Duration(sflags.MustGetDuration(cmd, FlagStallTimeoutBackproc))
 	}
 
-	var defaultSinkOptions []Option
-	if undoBufferSize > 0 {
-		defaultSinkOptions = append(defaultSinkOptions, WithBlockDataBuffer(undoBufferSize))
+	if sflags.FlagDefined(cmd, FlagStallTimeoutLive) {
+		config.StallTimeoutLive = Duration(sflags.MustGetDuration(cmd, FlagStallTimeoutLive))
 	}
 
-	if infiniteRetry {
-		defaultSinkOptions = append(defaultSinkOptions, WithInfiniteRetry())
+	if sflags.FlagDefined(cmd, FlagAdminListenAddr) {
+		config.AdminListenAddr = sflags.MustGetString(cmd, FlagAdminListenAddr)
 	}
 
-	if liveBlockTimeDelta > 0 {
-		defaultSinkOptions = append(defaultSinkOptions, WithLivenessChecker(NewDeltaLivenessChecker(liveBlockTimeDelta)))
+	if sflags.FlagDefined(cmd, FlagDryRun) {
+		config.DryRun = sflags.MustGetBool(cmd, FlagDryRun)
 	}
 
-	if finalBlocksOnly {
-		defaultSinkOptions = append(defaultSinkOptions, WithFinalBlocksOnly())
-	}
-
-	if resolvedBlockRange != nil {
-		defaultSinkOptions = append(defaultSinkOptions, WithBlockRange(resolvedBlockRange))
-	}
+	zlog.Info("sinker from CLI",
+		zap.String("endpoint", config.Endpoint),
+		zap.String("manifest_path", config.ManifestPath),
+		zap.Strings("params", config.Params),
+		zap.String("network", config.Network),
+		zap.String("output_module_name", config.OutputModule),
+		zap.Stringer("expected_module_type", expectedModuleType(config.ExpectedOutputModuleType)),
+		zap.String("block_range", config.BlockRange),
+		zap.Bool("development_mode", config.DevelopmentMode),
+		zap.Bool("infinite_retry", config.InfiniteRetry),
+		zap.Bool("final_blocks_only", config.FinalBlocksOnly),
+		zap.Bool("skip_package_validation", config.SkipPackageValidation),
+		zap.Duration("live_block_time_delta", liveBlockTimeDelta),
+		zap.Int("undo_buffer_size", config.UndoBufferSize),
+		zap.Strings("extra_headers", redactHeaders(config.Headers)),
+	)
 
-	if len(extraHeaders) > 0 {
-		defaultSinkOptions = append(defaultSinkOptions, WithExtraHeaders(extraHeaders))
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
 	}
 
-	return New(
-		mode,
-		pkg,
-		module,
-		outputModuleHash,
-		clientConfig,
-		zlog,
-		tracer,
-		append(defaultSinkOptions, opts...)...,
-	)
+	return newFromConfig(ctx, config, zlog, tracer, opts...)
 }
 
 func getViperFlags(cmd *cobra.Command) (
@@ -245,6 +322,8 @@ func getViperFlags(cmd *cobra.Command) (
 	finalBlocksOnly bool,
 	skipPackageValidation bool,
 	extraHeaders []string,
+	insecure bool,
+	plaintext bool,
 ) {
 	if sflags.FlagDefined(cmd, FlagParams) {
 		params = sflags.MustGetStringArray(cmd, FlagParams)
@@ -289,9 +368,86 @@ func getViperFlags(cmd *cobra.Command) (
 		extraHeaders = sflags.MustGetStringArray(cmd, FlagExtraHeaders)
 	}
 
+	if sflags.FlagDefined(cmd, FlagInsecure) {
+		insecure = sflags.MustGetBool(cmd, FlagInsecure)
+	}
+
+	if sflags.FlagDefined(cmd, FlagPlaintext) {
+		plaintext = sflags.MustGetBool(cmd, FlagPlaintext)
+	}
+
 	return
 }
 
+// getViperAuthFlags returns the [AuthConfig] configured by the auth flags, nil if none is set.
+func getViperAuthFlags(cmd *cobra.Command) *AuthConfig {
+	auth := &AuthConfig{}
+
+	if sflags.FlagDefined(cmd, FlagAuthTokenFile) {
+		auth.TokenFile = sflags.MustGetString(cmd, FlagAuthTokenFile)
+	}
+
+	if sflags.FlagDefined(cmd, FlagAuthCommand) {
+		auth.Command = strings.Fields(sflags.MustGetString(cmd, FlagAuthCommand))
+	}
+
+	if sflags.FlagDefined(cmd, FlagAuthURL) {
+		auth.URL = sflags.MustGetString(cmd, FlagAuthURL)
+	}
+
+	if auth.TokenFile == "" && len(auth.Command) == 0 && auth.URL == "" {
+		return nil
+	}
+
+	return auth
+}
+
+// getViperTLSFlags returns the [TLSConfig] configured by the TLS flags, nil if none is set.
+func getViperTLSFlags(cmd *cobra.Command) *TLSConfig {
+	tlsConfig := &TLSConfig{}
+
+	if sflags.FlagDefined(cmd, FlagTLSCAFile) {
+		tlsConfig.CAFile = sflags.MustGetString(cmd, FlagTLSCAFile)
+	}
+
+	if sflags.FlagDefined(cmd, FlagTLSCertFile) {
+		tlsConfig.CertFile = sflags.MustGetString(cmd, FlagTLSCertFile)
+	}
+
+	if sflags.FlagDefined(cmd, FlagTLSKeyFile) {
+		tlsConfig.KeyFile = sflags.MustGetString(cmd, FlagTLSKeyFile)
+	}
+
+	if sflags.FlagDefined(cmd, FlagTLSServerName) {
+		tlsConfig.ServerName = sflags.MustGetString(cmd, FlagTLSServerName)
+	}
+
+	if !tlsConfig.isSet() {
+		return nil
+	}
+
+	return tlsConfig
+}
+
+// getViperGRPCFlags sets the gRPC connection tuning fields of `config` from the gRPC flags.
+func getViperGRPCFlags(cmd *cobra.Command, config *SinkerConfig) {
+	if sflags.FlagDefined(cmd, FlagGRPCKeepAliveTime) {
+		config.GRPCKeepAliveTime = Duration(sflags.MustGetDuration(cmd, FlagGRPCKeepAliveTime))
+	}
+
+	if sflags.FlagDefined(cmd, FlagGRPCKeepAliveTimeout) {
+		config.GRPCKeepAliveTimeout = Duration(sflags.MustGetDuration(cmd, FlagGRPCKeepAliveTimeout))
+	}
+
+	if sflags.FlagDefined(cmd, FlagGRPCMaxRecvMsgSize) {
+		config.GRPCMaxRecvMessageSize = sflags.MustGetInt(cmd, FlagGRPCMaxRecvMsgSize)
+	}
+
+	if sflags.FlagDefined(cmd, FlagGRPCCompression) {
+		config.GRPCCompression = sflags.MustGetString(cmd, FlagGRPCCompression)
+	}
+}
+
 // parseNumber parses a number and indicates whether the number is relative, meaning it starts with a +
 func parseNumber(number string) (numberInt64 int64, numberIsEmpty bool, numberIsRelative bool, err error) {
 	if number == "" {
@@ -308,7 +464,124 @@ func parseNumber(number string) (numberInt64 int64, numberIsEmpty bool, numberIs
 	return
 }
 
-// ReadBlockRange parses a block range string and returns a bstream.Range out of it
+// parseBlockBoundary parses a block range boundary which is either a block number (see [parseNumber])
+// or a date, time or duration (see [parseBlockTime]), in which case `resolver` is used to
+// turn it into an absolute block number.
+func parseBlockBoundary(ctx context.Context, boundary string, resolver BlockTimeResolver) (numberInt64 int64, numberIsEmpty bool, numberIsRelative bool, err error) {
+	at, isTime, err := parseBlockTime(boundary, time.Now)
+	if err != nil {
+		return 0, false, false, err
+	}
+
+	if !isTime {
+		return parseNumber(boundary)
+	}
+
+	if resolver == nil {
+		return 0, false, false, fmt.Errorf("date and time block boundary requires a block time resolver")
+	}
+
+	blockNum, err := resolver.BlockAtTime(ctx, at)
+	if err != nil {
+		return 0, false, false, fmt.Errorf("resolve block at time %s: %w", at.Format(time.RFC3339), err)
+	}
+
+	return int64(blockNum), false, false, nil
+}
+
+var blockTimeRegex = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}(T\d{2}:\d{2}(:\d{2}(\.\d+)?)?(Z|[+-]\d{2}:\d{2})?)?`)
+var blockTimeDurationRegex = regexp.MustCompile(`^-(\d+(\.\d+)?[a-zµ]+)+$`)
+var blockTimeDurationComponentRegex = regexp.MustCompile(`(\d+(?:\.\d+)?)([a-zµ]+)`)
+
+var blockTimeLayouts = []string{
+	"2006-01-02T15:04:05Z07:00",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+// parseBlockTime parses a date, a date and time or a duration relative to `now`, `isTime`
+// is false if `boundary` is none of those, in which case it should be treated as a
+// block number.
+func parseBlockTime(boundary string, now func() time.Time) (at time.Time, isTime bool, err error) {
+	if blockTimeDurationRegex.MatchString(boundary) {
+		// Go durations have no days unit, we convert each `<number>d` component to hours first
+		var normalized strings.Builder
+		for _, component := range blockTimeDurationComponentRegex.FindAllStringSubmatch(boundary, -1) {
+			value, unit := component[1], component[2]
+			if unit == "d" {
+				days, err := strconv.ParseFloat(value, 64)
+				if err != nil {
+					return at, false, fmt.Errorf("invalid duration value: %w", err)
+				}
+
+				value, unit = strconv.FormatFloat(days*24, 'f', -1, 64), "h"
+			}
+
+			normalized.WriteString(value + unit)
+		}
+
+		duration, err := time.ParseDuration(normalized.String())
+		if err != nil {
+			return at, false, fmt.Errorf("invalid duration value: %w", err)
+		}
+
+		return now().Add(-duration), true, nil
+	}
+
+	if !blockTimeRegex.MatchString(boundary) {
+		return at, false, nil
+	}
+
+	for _, layout := range blockTimeLayouts {
+		if at, err = time.ParseInLocation(layout, boundary, time.UTC); err == nil {
+			return at, true, nil
+		}
+	}
+
+	return at, false, fmt.Errorf("invalid date or time value, expected a date like 2024-01-01 or date and time like 2024-01-01T00:00:00Z")
+}
+
+// cutBlockRange splits the block range on its `:` separator, taking care of
+// not splitting on the colons of a date and time `before` boundary.
+func cutBlockRange(input string) (before, after string, found bool) {
+	if loc := blockTimeRegex.FindStringIndex(input); loc != nil {
+		before, rest := input[:loc[1]], input[loc[1]:]
+		if rest == "" {
+			return before, "", false
+		}
+
+		if rest[0] == ':' {
+			return before, rest[1:], true
+		}
+	}
+
+	return strings.Cut(input, ":")
+}
+
+// ReadBlockRange parses a block range string and returns a [bstream.Range] out of it
+// using the model to resolve relative block numbers to absolute block numbers, see
+// [ReadBlockRanges] for the accepted syntax.
+//
+// An error is returned if the block range has multiple segments or a start block relative
+// to the chain's head block, use [ReadBlockRanges] to accept those.
+func ReadBlockRange(module *pbsubstreams.Module, input string) (*bstream.Range, error) {
+	return ReadBlockRangeWithTimeResolver(context.Background(), module, input, nil)
+}
+
+// ReadBlockRangeWithTimeResolver acts exactly like [ReadBlockRange] but also accepts
+// date and time boundaries, see [ReadBlockRangesWithTimeResolver].
+func ReadBlockRangeWithTimeResolver(ctx context.Context, module *pbsubstreams.Module, input string, resolver BlockTimeResolver) (*bstream.Range, error) {
+	blockRanges, err := ReadBlockRangesWithTimeResolver(ctx, module, input, resolver)
+	if err != nil {
+		return nil, err
+	}
+
+	return blockRanges.singleRange()
+}
+
+// ReadBlockRanges parses a block range string and returns the [BlockRanges] out of it
 // using the model to resolve relative block numbers to absolute block numbers.
 //
 // The block range string is of the form:
@@ -320,14 +593,88 @@ func parseNumber(number string) (numberInt64 int64, numberIsEmpty bool, numberIs
 // range is the entire chain.
 //
 // If before or after is prefixed with a +, it is relative to the module's start block.
-func ReadBlockRange(module *pbsubstreams.Module, input string) (*bstream.Range, error) {
+//
+// Multiple disjoint segments can be specified separated by a comma, for example `100:200,5000:5100`,
+// in which case they are processed sequentially by the [Sinker]. Segments must be ordered and must
+// not overlap, only the last segment can be open ended or have a start block relative to the chain's
+// head block. For segments after the first one, relative block numbers and empty before are relative
+// to the previous segment's end block instead of the module's start block, so `100:200,+10:+20`
+// is equivalent to `100:200,210:230`.
+//
+// If before is negative, it is relative to the chain's head block, for example `-1000:` starts
+// 1000 blocks before the chain's head block. The actual start block is resolved by the
+// Substreams backend when the stream starts, see [Sinker.BlockRange]. In that case, after
+// must be empty or an absolute block number.
+//
+// Date and time boundaries are not accepted by this function since there is no way
+// to resolve them, use [ReadBlockRangesWithTimeResolver] for that.
+func ReadBlockRanges(module *pbsubstreams.Module, input string) (BlockRanges, error) {
+	return ReadBlockRangesWithTimeResolver(context.Background(), module, input, nil)
+}
+
+// ReadBlockRangesWithTimeResolver acts exactly like [ReadBlockRanges] but also accepts
+// date and time boundaries for `before` and `after`, those are resolved to block
+// numbers using `resolver`, a time boundary resolves to the first block whose timestamp
+// is equal or after it. Accepted time boundaries are:
+//
+//   - A date `2024-01-01` (UTC)
+//   - A date and time `2024-01-01T00:00:00Z`, time zone offset is optional and defaults to UTC, seconds are optional
+//   - A duration prefixed with `-` like `-1h` or `-2d12h`, relative to now
+//
+// For example `2024-01-01:2024-02-01` streams January 2024 and `-1h:` streams from
+// one hour ago up to live blocks.
+//
+// A nil `resolver` is accepted in which case an error is returned if a time boundary
+// is used.
+func ReadBlockRangesWithTimeResolver(ctx context.Context, module *pbsubstreams.Module, input string, resolver BlockTimeResolver) (BlockRanges, error) {
 	if input == "" {
 		input = ":"
 	}
 
-	before, after, rangeHasStartAndStop := strings.Cut(input, ":")
+	segments := strings.Split(input, ",")
+	blockRanges := make(BlockRanges, len(segments))
+	for i, segment := range segments {
+		segment = strings.TrimSpace(segment)
+		if segment == "" {
+			return nil, fmt.Errorf("invalid range: segment #%d is empty", i)
+		}
+
+		// Relative and inferred block numbers of a segment are based on the previous segment's end block
+		relativeTo := module.InitialBlock
+		if i > 0 && blockRanges[i-1].EndBlock() != nil {
+			relativeTo = *blockRanges[i-1].EndBlock()
+		}
+
+		blockRange, err := readBlockRangeSegment(ctx, relativeTo, segment, resolver)
+		if err != nil {
+			if len(segments) == 1 {
+				return nil, err
+			}
 
-	beforeAsInt64, beforeIsEmpty, beforeIsRelative, err := parseNumber(before)
+			return nil, fmt.Errorf("segment %q: %w", segment, err)
+		}
+
+		blockRanges[i] = blockRange
+	}
+
+	if err := blockRanges.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid range: %w", err)
+	}
+
+	return blockRanges, nil
+}
+
+// readBlockRangeSegment reads a single block range segment, `relativeTo` is the block used to
+// infer an empty start block and to resolve relative block numbers, it's the module's start
+// block for the first segment.
+func readBlockRangeSegment(ctx context.Context, relativeTo uint64, input string, resolver BlockTimeResolver) (*BlockRange, error) {
+	if input == "" {
+		input = ":"
+	}
+
+	before, after, rangeHasStartAndStop := cutBlockRange(input)
+
+	beforeAsInt64, beforeIsEmpty, beforeIsRelative, err := parseBlockBoundary(ctx, before, resolver)
 	if err != nil {
 		return nil, fmt.Errorf("parse number %q: %w", before, err)
 	}
@@ -339,7 +686,7 @@ func ReadBlockRange(module *pbsubstreams.Module, input string) (*bstream.Range,
 
 	afterAsInt64, afterIsEmpty, afterIsRelative := int64(0), false, false
 	if rangeHasStartAndStop {
-		afterAsInt64, afterIsEmpty, afterIsRelative, err = parseNumber(after)
+		afterAsInt64, afterIsEmpty, afterIsRelative, err = parseBlockBoundary(ctx, after, resolver)
 		if err != nil {
 			return nil, fmt.Errorf("parse number %q: %w", after, err)
 		}
@@ -348,27 +695,44 @@ func ReadBlockRange(module *pbsubstreams.Module, input string) (*bstream.Range,
 	if !rangeHasStartAndStop {
 		// If there is no `:` we assume it's a stop block value right away
 		if beforeAsInt64 < 1 {
-			return bstream.NewOpenRange(module.InitialBlock), nil
+			return NewBlockRange(int64(relativeTo), nil), nil
 		}
 
-		start := module.InitialBlock
+		start := relativeTo
 		stop := resolveBlockNumber(beforeAsInt64, 0, beforeIsRelative, int64(start))
 
 		if int64(start) >= stop {
 			return nil, fmt.Errorf("invalid range: start block %d is equal or above stop block %d (exclusive)", start, stop)
 		}
 
-		return bstream.NewRangeExcludingEnd(start, uint64(stop)), nil
+		return NewBlockRange(int64(start), uint64Ptr(uint64(stop))), nil
 	} else {
 		// Otherwise, we have a `:` sign so we assume it's a start/stop range
-		start := resolveBlockNumber(beforeAsInt64, int64(module.InitialBlock), beforeIsRelative, int64(module.InitialBlock))
+		if !beforeIsRelative && beforeAsInt64 < 0 {
+			// A negative start block is relative to chain's head block, it's resolved by the Substreams backend
+			if afterIsEmpty || afterAsInt64 == -1 {
+				return NewBlockRange(beforeAsInt64, nil), nil
+			}
+
+			if afterIsRelative {
+				return nil, fmt.Errorf("invalid range: relative stop block %q cannot be used with start block %d which is relative to chain's head block", after, beforeAsInt64)
+			}
+
+			if afterAsInt64 < 0 {
+				return nil, fmt.Errorf("invalid range: stop block %d must be positive when start block %d is relative to chain's head block", afterAsInt64, beforeAsInt64)
+			}
+
+			return NewBlockRange(beforeAsInt64, uint64Ptr(uint64(afterAsInt64))), nil
+		}
+
+		start := resolveBlockNumber(beforeAsInt64, int64(relativeTo), beforeIsRelative, int64(relativeTo))
 		if afterAsInt64 == -1 {
-			return bstream.NewOpenRange(uint64(start)), nil
+			return NewBlockRange(start, nil), nil
 		}
 
 		startBlock := uint64(start)
 		if afterIsEmpty {
-			return bstream.NewOpenRange(startBlock), nil
+			return NewBlockRange(start, nil), nil
 		}
 
 		exclusiveEndBlock := uint64(resolveBlockNumber(afterAsInt64, 0, afterIsRelative, start))
@@ -377,7 +741,7 @@ func ReadBlockRange(module *pbsubstreams.Module, input string) (*bstream.Range,
 			return nil, fmt.Errorf("invalid range: start block %d is equal or above stop block %d (exclusive)", startBlock, exclusiveEndBlock)
 		}
 
-		return bstream.NewRangeExcludingEnd(startBlock, exclusiveEndBlock), nil
+		return NewBlockRange(start, uint64Ptr(exclusiveEndBlock)), nil
 	}
 }
 
@@ -400,6 +764,10 @@ func readAPIToken() string {
 	return os.Getenv("SF_API_TOKEN")
 }
 
+func uint64Ptr(value uint64) *uint64 {
+	return &value
+}
+
 func every[E any](s []E, test func(e E) bool) bool {
 	for _, element := range s {
 		if !test(element) {
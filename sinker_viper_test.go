@@ -1,7 +1,10 @@
 package sink
 
 import (
+	"context"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/spf13/pflag"
 	"github.com/streamingfast/bstream"
@@ -32,6 +35,21 @@ func TestAddFlagsToSet(t *testing.T) {
 				FlagIrreversibleOnly,
 				FlagSkipPackageValidation,
 				FlagExtraHeaders,
+				FlagAuthTokenFile,
+				FlagAuthCommand,
+				FlagAuthURL,
+				FlagTLSCAFile,
+				FlagTLSCertFile,
+				FlagTLSKeyFile,
+				FlagTLSServerName,
+				FlagGRPCKeepAliveTime,
+				FlagGRPCKeepAliveTimeout,
+				FlagGRPCMaxRecvMsgSize,
+				FlagGRPCCompression,
+				FlagStallTimeoutBackproc,
+				FlagStallTimeoutLive,
+				FlagAdminListenAddr,
+				FlagDryRun,
 			},
 		},
 		{
@@ -49,6 +67,21 @@ func TestAddFlagsToSet(t *testing.T) {
 				FlagIrreversibleOnly,
 				FlagSkipPackageValidation,
 				FlagExtraHeaders,
+				FlagAuthTokenFile,
+				FlagAuthCommand,
+				FlagAuthURL,
+				FlagTLSCAFile,
+				FlagTLSCertFile,
+				FlagTLSKeyFile,
+				FlagTLSServerName,
+				FlagGRPCKeepAliveTime,
+				FlagGRPCKeepAliveTimeout,
+				FlagGRPCMaxRecvMsgSize,
+				FlagGRPCCompression,
+				FlagStallTimeoutBackproc,
+				FlagStallTimeoutLive,
+				FlagAdminListenAddr,
+				FlagDryRun,
 			},
 		},
 		{
@@ -65,6 +98,21 @@ func TestAddFlagsToSet(t *testing.T) {
 				FlagIrreversibleOnly,
 				FlagSkipPackageValidation,
 				FlagExtraHeaders,
+				FlagAuthTokenFile,
+				FlagAuthCommand,
+				FlagAuthURL,
+				FlagTLSCAFile,
+				FlagTLSCertFile,
+				FlagTLSKeyFile,
+				FlagTLSServerName,
+				FlagGRPCKeepAliveTime,
+				FlagGRPCKeepAliveTimeout,
+				FlagGRPCMaxRecvMsgSize,
+				FlagGRPCCompression,
+				FlagStallTimeoutBackproc,
+				FlagStallTimeoutLive,
+				FlagAdminListenAddr,
+				FlagDryRun,
 			},
 		},
 		{
@@ -81,6 +129,21 @@ func TestAddFlagsToSet(t *testing.T) {
 				FlagIrreversibleOnly,
 				FlagSkipPackageValidation,
 				FlagExtraHeaders,
+				FlagAuthTokenFile,
+				FlagAuthCommand,
+				FlagAuthURL,
+				FlagTLSCAFile,
+				FlagTLSCertFile,
+				FlagTLSKeyFile,
+				FlagTLSServerName,
+				FlagGRPCKeepAliveTime,
+				FlagGRPCKeepAliveTimeout,
+				FlagGRPCMaxRecvMsgSize,
+				FlagGRPCCompression,
+				FlagStallTimeoutBackproc,
+				FlagStallTimeoutLive,
+				FlagAdminListenAddr,
+				FlagDryRun,
 			},
 		},
 		{
@@ -96,6 +159,21 @@ func TestAddFlagsToSet(t *testing.T) {
 				FlagIrreversibleOnly,
 				FlagSkipPackageValidation,
 				FlagExtraHeaders,
+				FlagAuthTokenFile,
+				FlagAuthCommand,
+				FlagAuthURL,
+				FlagTLSCAFile,
+				FlagTLSCertFile,
+				FlagTLSKeyFile,
+				FlagTLSServerName,
+				FlagGRPCKeepAliveTime,
+				FlagGRPCKeepAliveTimeout,
+				FlagGRPCMaxRecvMsgSize,
+				FlagGRPCCompression,
+				FlagStallTimeoutBackproc,
+				FlagStallTimeoutLive,
+				FlagAdminListenAddr,
+				FlagDryRun,
 			},
 		},
 		{
@@ -112,6 +190,21 @@ func TestAddFlagsToSet(t *testing.T) {
 				FlagInfiniteRetry,
 				FlagSkipPackageValidation,
 				FlagExtraHeaders,
+				FlagAuthTokenFile,
+				FlagAuthCommand,
+				FlagAuthURL,
+				FlagTLSCAFile,
+				FlagTLSCertFile,
+				FlagTLSKeyFile,
+				FlagTLSServerName,
+				FlagGRPCKeepAliveTime,
+				FlagGRPCKeepAliveTimeout,
+				FlagGRPCMaxRecvMsgSize,
+				FlagGRPCCompression,
+				FlagStallTimeoutBackproc,
+				FlagStallTimeoutLive,
+				FlagAdminListenAddr,
+				FlagDryRun,
 			},
 		},
 		{
@@ -129,6 +222,21 @@ func TestAddFlagsToSet(t *testing.T) {
 				FlagInfiniteRetry,
 				FlagSkipPackageValidation,
 				FlagExtraHeaders,
+				FlagAuthTokenFile,
+				FlagAuthCommand,
+				FlagAuthURL,
+				FlagTLSCAFile,
+				FlagTLSCertFile,
+				FlagTLSKeyFile,
+				FlagTLSServerName,
+				FlagGRPCKeepAliveTime,
+				FlagGRPCKeepAliveTimeout,
+				FlagGRPCMaxRecvMsgSize,
+				FlagGRPCCompression,
+				FlagStallTimeoutBackproc,
+				FlagStallTimeoutLive,
+				FlagAdminListenAddr,
+				FlagDryRun,
 			},
 		},
 	}
@@ -148,19 +256,26 @@ func TestAddFlagsToSet(t *testing.T) {
 	}
 }
 
-func Test_readBlockRange(t *testing.T) {
+func Test_readBlockRanges(t *testing.T) {
 	errorIs := func(errString string) require.ErrorAssertionFunc {
 		return func(tt require.TestingT, err error, i ...interface{}) {
 			require.EqualError(tt, err, errString, i...)
 		}
 	}
 
-	openRange := func(start uint64) *bstream.Range {
-		return bstream.NewOpenRange(start)
+	openRange := func(start int64) BlockRanges {
+		return BlockRanges{NewBlockRange(start, nil)}
 	}
 
-	closedRange := func(start, end uint64) *bstream.Range {
-		return bstream.NewRangeExcludingEnd(start, end)
+	closedRange := func(start int64, end uint64) BlockRanges {
+		return BlockRanges{NewBlockRange(start, &end)}
+	}
+
+	segments := func(in ...BlockRanges) (out BlockRanges) {
+		for _, blockRanges := range in {
+			out = append(out, blockRanges...)
+		}
+		return
 	}
 
 	type args struct {
@@ -170,7 +285,7 @@ func Test_readBlockRange(t *testing.T) {
 	tests := []struct {
 		name      string
 		args      args
-		want      *bstream.Range
+		want      BlockRanges
 		assertion require.ErrorAssertionFunc
 	}{
 		// Single
@@ -191,8 +306,29 @@ func Test_readBlockRange(t *testing.T) {
 
 		{"range start, stop+", args{5, "10:+10"}, closedRange(10, 20), nil},
 
+		{"range head relative start, <empty>", args{5, "-1000:"}, openRange(-1000), nil},
+		{"range head relative start, -1", args{5, "-1000:-1"}, openRange(-1000), nil},
+		{"range head relative start, stop", args{5, "-1000:20000"}, closedRange(-1000, 20000), nil},
+
 		{"error invalid range, equal", args{0, "10:10"}, nil, errorIs("invalid range: start block 10 is equal or above stop block 10 (exclusive)")},
 		{"error invalid range, over", args{0, "11:10"}, nil, errorIs("invalid range: start block 11 is equal or above stop block 10 (exclusive)")},
+		{"error head relative start, stop+", args{0, "-1000:+10"}, nil, errorIs(`invalid range: relative stop block "+10" cannot be used with start block -1000 which is relative to chain's head block`)},
+		{"error head relative start, negative stop", args{0, "-1000:-10"}, nil, errorIs("invalid range: stop block -10 must be positive when start block -1000 is relative to chain's head block")},
+
+		// Multiple segments
+		{"segments closed", args{5, "100:200,5000:5100"}, segments(closedRange(100, 200), closedRange(5000, 5100)), nil},
+		{"segments relative to previous", args{5, "100:200,5000:5100,+10:+20"}, segments(closedRange(100, 200), closedRange(5000, 5100), closedRange(5110, 5130)), nil},
+		{"segments first relative to module", args{5, "+10:+20,100:200"}, segments(closedRange(15, 35), closedRange(100, 200)), nil},
+		{"segments inferred start", args{5, "100:200,:300,400"}, segments(closedRange(100, 200), closedRange(200, 300), closedRange(300, 400)), nil},
+		{"segments contiguous", args{5, "100:200,200:300"}, segments(closedRange(100, 200), closedRange(200, 300)), nil},
+		{"segments last open", args{5, "100:200, 5000:"}, segments(closedRange(100, 200), openRange(5000)), nil},
+		{"segments last head relative", args{5, "100:200,-1000:"}, segments(closedRange(100, 200), openRange(-1000)), nil},
+
+		{"error segments overlap", args{5, "100:200,150:300"}, nil, errorIs("invalid range: segment [100, 200): must end at or before next segment [150, 300) start block")},
+		{"error segments open not last", args{5, "100:,5000:5100"}, nil, errorIs("invalid range: segment [100, nil]: only the last segment can be open ended")},
+		{"error segments head relative not last", args{5, "-1000:20000,50000:50100"}, nil, errorIs("invalid range: segment [head-1000, 20000): only the last segment can have a start block relative to chain's head block")},
+		{"error segments empty", args{5, "100:200,,5000:5100"}, nil, errorIs("invalid range: segment #1 is empty")},
+		{"error segments invalid", args{5, "100:200,11:10"}, nil, errorIs(`segment "11:10": invalid range: start block 11 is equal or above stop block 10 (exclusive)`)},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -200,7 +336,107 @@ func Test_readBlockRange(t *testing.T) {
 				InitialBlock: tt.args.moduleStartBlock,
 			}
 
-			got, err := ReadBlockRange(module, tt.args.blockRangeArg)
+			got, err := ReadBlockRanges(module, tt.args.blockRangeArg)
+
+			if tt.assertion == nil {
+				tt.assertion = require.NoError
+			}
+
+			tt.assertion(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_readBlockRange(t *testing.T) {
+	tests := []struct {
+		name          string
+		blockRangeArg string
+		want          *bstream.Range
+		expectedError string
+	}{
+		{"empty is full range", "", bstream.NewOpenRange(5), ""},
+		{"range start, stop", "10:12", bstream.NewRangeExcludingEnd(10, 12), ""},
+		{"range start+, stop+", "+10:+10", bstream.NewRangeExcludingEnd(15, 25), ""},
+
+		{"error invalid range", "11:10", nil, "invalid range: start block 11 is equal or above stop block 10 (exclusive)"},
+		{"error head relative", "-1000:", nil, "block range [head-1000, nil] is relative to chain's head block, only an absolute block range is accepted here"},
+		{"error segments", "100:200,5000:5100", nil, "block range [100, 200), [5000, 5100) has 2 segments, only a single segment is accepted here"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadBlockRange(&pbsubstreams.Module{InitialBlock: 5}, tt.blockRangeArg)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+type mapBlockTimeResolver map[string]uint64
+
+func (r mapBlockTimeResolver) BlockAtTime(ctx context.Context, at time.Time) (uint64, error) {
+	blockNum, found := r[at.UTC().Format(time.RFC3339)]
+	if !found {
+		return 0, fmt.Errorf("no block at %s", at.UTC().Format(time.RFC3339))
+	}
+
+	return blockNum, nil
+}
+
+func Test_readBlockRangesWithTimeResolver(t *testing.T) {
+	errorIs := func(errString string) require.ErrorAssertionFunc {
+		return func(tt require.TestingT, err error, i ...interface{}) {
+			require.EqualError(tt, err, errString, i...)
+		}
+	}
+
+	openRange := func(start int64) BlockRanges {
+		return BlockRanges{NewBlockRange(start, nil)}
+	}
+
+	closedRange := func(start int64, end uint64) BlockRanges {
+		return BlockRanges{NewBlockRange(start, &end)}
+	}
+
+	resolver := mapBlockTimeResolver{
+		"2024-01-01T00:00:00Z": 100,
+		"2024-01-15T12:30:00Z": 150,
+		"2024-02-01T00:00:00Z": 200,
+	}
+
+	tests := []struct {
+		name          string
+		blockRangeArg string
+		resolver      BlockTimeResolver
+		want          BlockRanges
+		assertion     require.ErrorAssertionFunc
+	}{
+		{"single date is stop block", "2024-02-01", resolver, closedRange(5, 200), nil},
+		{"range dates", "2024-01-01:2024-02-01", resolver, closedRange(100, 200), nil},
+		{"range date time, date", "2024-01-01T00:00:00Z:2024-02-01", resolver, closedRange(100, 200), nil},
+		{"range date time offset, <empty>", "2024-01-15T14:30:00+02:00:", resolver, openRange(150), nil},
+		{"range date time no seconds, <empty>", "2024-01-15T12:30:", resolver, openRange(150), nil},
+		{"range date, stop+", "2024-01-01:+10", resolver, closedRange(100, 110), nil},
+		{"range start, date", "120:2024-02-01", resolver, closedRange(120, 200), nil},
+		{"range numbers still work", "10:20", resolver, closedRange(10, 20), nil},
+
+		{"error no resolver", "2024-01-01:", nil, nil, errorIs(`parse number "2024-01-01": date and time block boundary requires a block time resolver`)},
+		{"error invalid date", "2024-13-01:", resolver, nil, errorIs(`parse number "2024-13-01": invalid date or time value, expected a date like 2024-01-01 or date and time like 2024-01-01T00:00:00Z`)},
+		{"error invalid duration", "-1y:", resolver, nil, errorIs(`parse number "-1y": invalid duration value: time: unknown unit "y" in duration "1y"`)},
+		{"error resolver", "2023-01-01:", resolver, nil, errorIs(`parse number "2023-01-01": resolve block at time 2023-01-01T00:00:00Z: no block at 2023-01-01T00:00:00Z`)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			module := &pbsubstreams.Module{
+				InitialBlock: 5,
+			}
+
+			got, err := ReadBlockRangesWithTimeResolver(context.Background(), module, tt.blockRangeArg, tt.resolver)
 
 			if tt.assertion == nil {
 				tt.assertion = require.NoError
@@ -211,3 +447,35 @@ func Test_readBlockRange(t *testing.T) {
 		})
 	}
 }
+
+func Test_parseBlockTime(t *testing.T) {
+	now := func() time.Time { return time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		boundary   string
+		wantAt     time.Time
+		wantIsTime bool
+	}{
+		{"", time.Time{}, false},
+		{"10", time.Time{}, false},
+		{"+10", time.Time{}, false},
+		{"-1", time.Time{}, false},
+		{"-1h", time.Date(2024, 1, 10, 11, 0, 0, 0, time.UTC), true},
+		{"-90m", time.Date(2024, 1, 10, 10, 30, 0, 0, time.UTC), true},
+		{"-2d12h", time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC), true},
+		{"-1.5d", time.Date(2024, 1, 9, 0, 0, 0, 0, time.UTC), true},
+		{"-0.5d1.5h", time.Date(2024, 1, 9, 22, 30, 0, 0, time.UTC), true},
+		{"2024-01-01", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), true},
+		{"2024-01-01T10:20", time.Date(2024, 1, 1, 10, 20, 0, 0, time.UTC), true},
+		{"2024-01-01T10:20:30Z", time.Date(2024, 1, 1, 10, 20, 30, 0, time.UTC), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.boundary, func(t *testing.T) {
+			at, isTime, err := parseBlockTime(tt.boundary, now)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.wantIsTime, isTime)
+			assert.True(t, tt.wantAt.Equal(at), "expected %s, got %s", tt.wantAt, at)
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package sink
 
 import (
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/streamingfast/bstream"
@@ -16,8 +18,11 @@ type Stats struct {
 	progressBlockRate *dmetrics.AvgRatePromGauge
 	undoMsgRate       *dmetrics.AvgRatePromCounter
 
-	lastBlock bstream.BlockRef
-	logger    *zap.Logger
+	// lastBlockMu guards lastBlock, recorded while streaming and read from other goroutines
+	lastBlockMu sync.Mutex
+	lastBlock   bstream.BlockRef
+	paused      atomic.Bool
+	logger      *zap.Logger
 }
 
 func newStats(logger *zap.Logger) *Stats {
@@ -35,9 +40,26 @@ func newStats(logger *zap.Logger) *Stats {
 }
 
 func (s *Stats) RecordBlock(block bstream.BlockRef) {
+	s.lastBlockMu.Lock()
+	defer s.lastBlockMu.Unlock()
+
 	s.lastBlock = block
 }
 
+// LastBlock returns the last block recorded, see [Stats.RecordBlock]. It's safe to call
+// concurrently with [Stats.RecordBlock].
+func (s *Stats) LastBlock() bstream.BlockRef {
+	s.lastBlockMu.Lock()
+	defer s.lastBlockMu.Unlock()
+
+	return s.lastBlock
+}
+
+// RecordPaused records whether the [Sinker] is paused, see [Sinker.Pause].
+func (s *Stats) RecordPaused(paused bool) {
+	s.paused.Store(paused)
+}
+
 func (s *Stats) Start(each time.Duration) {
 	if s.IsTerminating() || s.IsTerminated() {
 		panic("already shutdown, refusing to start again")
@@ -72,7 +94,8 @@ func (s *Stats) LogNow() {
 		zap.Uint64("progress_total_processed_blocks", dmetrics.NewValueFromMetric(ProgressMessageTotalProcessedBlocks, "blocks").ValueUint()),
 		zap.Any("progress_last_contiguous_block", dmetrics.NewValuesFromMetric(ProgressMessageLastContiguousBlock).Uints("stage")),
 
-		zap.Stringer("last_block", s.lastBlock),
+		zap.Stringer("last_block", s.LastBlock()),
+		zap.Bool("paused", s.paused.Load()),
 	)
 }
 
@@ -0,0 +1,215 @@
+package sink
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/streamingfast/bstream"
+	"go.uber.org/zap"
+)
+
+var (
+	// errForcedReconnect is the cause of the stream cancellation requested by [Sinker.Reconnect].
+	errForcedReconnect = errors.New("reconnection requested")
+
+	// errStopBlockReached is returned by [Sinker.doRequest] once the block requested through
+	// [Sinker.StopAtBlock] was handled.
+	errStopBlockReached = errors.New("requested stop block reached")
+)
+
+// SinkerStatus is a snapshot of the runtime state of a [Sinker], see [Sinker.Status].
+type SinkerStatus struct {
+	// Cursor is the cursor of the last block handled by the handler, empty if none was.
+	Cursor string `json:"cursor"`
+	// LastHandledBlock is the last block handled by the handler.
+	LastHandledBlock *BlockStatus `json:"last_handled_block,omitempty"`
+	// LastReceivedBlock is the last block received from the stream, it's ahead of
+	// LastHandledBlock when blocks are buffered.
+	LastReceivedBlock *BlockStatus `json:"last_received_block,omitempty"`
+	// BlockRange is the block range segment currently streamed.
+	BlockRange string `json:"block_range"`
+	// OutputModuleHash is the hash of the output module.
+	OutputModuleHash string `json:"output_module_hash"`
+	// Endpoint is the address of the endpoint currently streamed from.
+	Endpoint string `json:"endpoint"`
+	// Buffer summarizes the undo buffer, nil when there is none.
+	Buffer *BufferSummary `json:"buffer,omitempty"`
+	// Live is the liveness of the last block handled, nil when no [LivenessChecker] is configured.
+	Live *bool `json:"live,omitempty"`
+	// Retry is the state of the retry logic.
+	Retry RetryState `json:"retry"`
+	// Paused is true when the sinker is paused, see [Sinker.Pause].
+	Paused bool `json:"paused"`
+	// PausedSince is the time at which the sinker was paused.
+	PausedSince *time.Time `json:"paused_since,omitempty"`
+	// StopAtBlock is the block requested through [Sinker.StopAtBlock], 0 when none.
+	StopAtBlock uint64 `json:"stop_at_block,omitempty"`
+}
+
+type BlockStatus struct {
+	Number uint64 `json:"number"`
+	ID     string `json:"id"`
+}
+
+func newBlockStatus(block bstream.BlockRef) *BlockStatus {
+	return &BlockStatus{Number: block.Num(), ID: block.ID()}
+}
+
+// BufferSummary summarizes the content of the undo buffer.
+type BufferSummary struct {
+	Capacity         int          `json:"capacity"`
+	Length           int          `json:"length"`
+	OldestBlock      *BlockStatus `json:"oldest_block,omitempty"`
+	NewestBlock      *BlockStatus `json:"newest_block,omitempty"`
+	LastEmittedBlock *BlockStatus `json:"last_emitted_block,omitempty"`
+}
+
+// RetryState is the state of the [Sinker]'s retry logic.
+type RetryState struct {
+	// Attempts is the number of consecutive retries without receiving any message.
+	Attempts int `json:"attempts"`
+	// LastError is the last retryable error encountered.
+	LastError string `json:"last_error,omitempty"`
+	// LastErrorAt is the time at which LastError was encountered.
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+	// NextAttemptAt is the time of the next connection attempt when currently waiting to retry.
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+}
+
+// runtimeState is the state of the [Sinker] updated while streaming and read concurrently.
+type runtimeState struct {
+	mu                sync.Mutex
+	lastHandledCursor *Cursor
+	lastHandledBlock  bstream.BlockRef
+	lastReceivedBlock bstream.BlockRef
+	// requestedBlockRange is the block range segment currently streamed as requested and
+	// blockRange its absolute counterpart, nil until a head relative start block is resolved.
+	requestedBlockRange *BlockRange
+	blockRange          *bstream.Range
+	buffer              *BufferSummary
+	live                *bool
+	retry               RetryState
+	stopAtBlock         uint64
+	blocksProcessed     uint64
+	cancelStream        context.CancelCauseFunc
+}
+
+func (s *runtimeState) update(f func(state *runtimeState)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f(s)
+}
+
+// Status returns a snapshot of the runtime state of the sinker, it's safe to call
+// concurrently with [Sinker.Run].
+func (s *Sinker) Status() *SinkerStatus {
+	status := &SinkerStatus{
+		OutputModuleHash: s.outputModuleHash,
+		Endpoint:         s.ActiveEndpoint().Address,
+	}
+
+	paused, since := s.Paused()
+	status.Paused = paused
+	if paused {
+		status.PausedSince = &since
+	}
+
+	s.state.mu.Lock()
+	defer s.state.mu.Unlock()
+
+	status.Cursor = s.state.lastHandledCursor.String()
+	if s.state.lastHandledBlock != nil {
+		status.LastHandledBlock = newBlockStatus(s.state.lastHandledBlock)
+	}
+	if s.state.lastReceivedBlock != nil {
+		status.LastReceivedBlock = newBlockStatus(s.state.lastReceivedBlock)
+	}
+
+	status.BlockRange = s.state.requestedBlockRange.String()
+	if s.state.blockRange != nil {
+		status.BlockRange = s.state.blockRange.String()
+	}
+	status.Buffer = s.state.buffer
+	status.Live = s.state.live
+	status.Retry = s.state.retry
+	status.StopAtBlock = s.state.stopAtBlock
+
+	return status
+}
+
+// RetryState returns the state of the retry logic, see [RetryState].
+func (s *Sinker) RetryState() RetryState {
+	s.state.mu.Lock()
+	defer s.state.mu.Unlock()
+
+	return s.state.retry
+}
+
+// Reconnect closes the current stream and the connection to the endpoint, then reconnects
+// from the last cursor received, the handler call in progress, if any, completes normally.
+func (s *Sinker) Reconnect() {
+	s.state.mu.Lock()
+	defer s.state.mu.Unlock()
+
+	if s.state.cancelStream != nil {
+		s.logger.Info("reconnection requested, closing current stream")
+		s.state.cancelStream(errForcedReconnect)
+	}
+}
+
+// StopAtBlock terminates the sinker without error once the handler handled a block whose
+// number is greater or equal to `block`, without calling [SinkerCompletionHandler] since the
+// requested block range is not completed. A `block` of 0 cancels a previous request.
+func (s *Sinker) StopAtBlock(block uint64) {
+	s.logger.Info("stop at block requested", zap.Uint64("block_num", block))
+
+	s.state.update(func(state *runtimeState) {
+		state.stopAtBlock = block
+	})
+}
+
+func (s *Sinker) recordRetry(err error, sleepFor time.Duration) {
+	now := time.Now()
+
+	s.state.update(func(state *runtimeState) {
+		state.retry.Attempts++
+		state.retry.LastError = err.Error()
+		state.retry.LastErrorAt = &now
+		state.retry.NextAttemptAt = nil
+
+		if sleepFor != backoff.Stop {
+			nextAttemptAt := now.Add(sleepFor)
+			state.retry.NextAttemptAt = &nextAttemptAt
+		}
+	})
+}
+
+// lastHandledCursor returns the cursor of the last block handled by the handler, nil if none was.
+func (s *Sinker) lastHandledCursor() *Cursor {
+	s.state.mu.Lock()
+	defer s.state.mu.Unlock()
+
+	return s.state.lastHandledCursor
+}
+
+// recordHandled records the block handled by the handler, returns true if the block requested
+// through [Sinker.StopAtBlock] has been reached.
+func (s *Sinker) recordHandled(cursor *Cursor, block bstream.BlockRef, isLive *bool) (stop bool) {
+	s.state.mu.Lock()
+	defer s.state.mu.Unlock()
+
+	s.state.lastHandledCursor = cursor
+	s.state.lastHandledBlock = block
+	s.state.blocksProcessed++
+	s.state.live = nil
+	if isLive != nil {
+		live := *isLive
+		s.state.live = &live
+	}
+
+	return s.state.stopAtBlock != 0 && block.Num() >= s.state.stopAtBlock
+}
@@ -0,0 +1,118 @@
+package sink
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// TLSConfig customizes the TLS settings used to connect to the Substreams endpoint, for example
+// to reach a self-hosted Substreams tier behind an internal CA requiring client certificates.
+//
+// Files are read each time the [Sinker] connects to the endpoint, so rotated certificates are
+// picked up on reconnection.
+type TLSConfig struct {
+	// CAFile is a PEM bundle of the CAs used to verify the server certificate, replacing the
+	// system's CAs, see flag `--tls-ca-file`.
+	CAFile string `yaml:"ca_file,omitempty" json:"ca_file,omitempty"`
+	// CertFile is the PEM client certificate presented to the server (mTLS), requires KeyFile,
+	// see flag `--tls-cert-file`.
+	CertFile string `yaml:"cert_file,omitempty" json:"cert_file,omitempty"`
+	// KeyFile is the PEM private key of CertFile, see flag `--tls-key-file`.
+	KeyFile string `yaml:"key_file,omitempty" json:"key_file,omitempty"`
+	// ServerName overrides the server name used to verify the server certificate, see flag
+	// `--tls-server-name`.
+	ServerName string `yaml:"server_name,omitempty" json:"server_name,omitempty"`
+}
+
+func (c *TLSConfig) isSet() bool {
+	return c != nil && (c.CAFile != "" || c.CertFile != "" || c.KeyFile != "" || c.ServerName != "")
+}
+
+func (c *TLSConfig) clone() *TLSConfig {
+	if c == nil {
+		return nil
+	}
+
+	cloned := *c
+	return &cloned
+}
+
+// Validate validates the config without reading the files.
+func (c *TLSConfig) Validate() error {
+	if c == nil {
+		return nil
+	}
+
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		return errors.New("tls client certificate and key files must be provided together")
+	}
+
+	return nil
+}
+
+// load reads the files and returns the equivalent [tls.Config], a nil config returns the
+// default [tls.Config].
+func (c *TLSConfig) load(insecure bool) (*tls.Config, error) {
+	config := &tls.Config{InsecureSkipVerify: insecure}
+	if c == nil {
+		return config, nil
+	}
+
+	config.ServerName = c.ServerName
+
+	if c.CAFile != "" {
+		content, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read tls CA file: %w", err)
+		}
+
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(content) {
+			return nil, fmt.Errorf("tls CA file %q contains no PEM certificate", c.CAFile)
+		}
+	}
+
+	if c.CertFile != "" {
+		certificate, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load tls client certificate: %w", err)
+		}
+
+		config.Certificates = []tls.Certificate{certificate}
+	}
+
+	return config, nil
+}
+
+// tlsMode describes the TLS mode of a connection, for logging purposes.
+func tlsMode(config *TLSConfig, insecure bool, plaintext bool) string {
+	if plaintext {
+		return "plaintext"
+	}
+
+	mode := "tls"
+	if insecure {
+		mode = "tls without verification"
+	}
+
+	if !config.isSet() {
+		return mode
+	}
+
+	if config.CertFile != "" {
+		mode = "mutual " + mode
+	}
+
+	if config.CAFile != "" {
+		mode += ", custom CA"
+	}
+
+	if config.ServerName != "" {
+		mode += ", server name " + config.ServerName
+	}
+
+	return mode
+}
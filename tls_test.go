@@ -0,0 +1,197 @@
+package sink
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/streamingfast/substreams/client"
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+)
+
+func TestTLSConfig_Validate(t *testing.T) {
+	assert.NoError(t, (*TLSConfig)(nil).Validate())
+	assert.NoError(t, (&TLSConfig{CAFile: "ca.pem"}).Validate())
+	assert.NoError(t, (&TLSConfig{CertFile: "cert.pem", KeyFile: "key.pem"}).Validate())
+	assert.EqualError(t, (&TLSConfig{CertFile: "cert.pem"}).Validate(), "tls client certificate and key files must be provided together")
+	assert.EqualError(t, (&TLSConfig{KeyFile: "key.pem"}).Validate(), "tls client certificate and key files must be provided together")
+}
+
+func Test_tlsMode(t *testing.T) {
+	tests := []struct {
+		config    *TLSConfig
+		insecure  bool
+		plaintext bool
+		expected  string
+	}{
+		{nil, false, false, "tls"},
+		{nil, true, false, "tls without verification"},
+		{&TLSConfig{CAFile: "ca.pem"}, false, true, "plaintext"},
+		{&TLSConfig{CAFile: "ca.pem"}, false, false, "tls, custom CA"},
+		{&TLSConfig{CertFile: "cert.pem", KeyFile: "key.pem"}, false, false, "mutual tls"},
+		{&TLSConfig{CAFile: "ca.pem", CertFile: "cert.pem", KeyFile: "key.pem", ServerName: "substreams.internal"}, false, false, "mutual tls, custom CA, server name substreams.internal"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tlsMode(tt.config, tt.insecure, tt.plaintext))
+		})
+	}
+}
+
+func Test_newSubstreamsClient_MutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCertificateAuthority(t)
+	ca.writePEM(t, filepath.Join(dir, "ca.pem"))
+
+	serverCert := ca.issue(t, "substreams.internal", false)
+	clientCert := ca.issue(t, "sink", true)
+	clientCert.writePEM(t, filepath.Join(dir, "client.pem"), filepath.Join(dir, "client-key.pem"))
+
+	server := grpc.NewServer(grpc.Creds(credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{serverCert.tlsCertificate},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    ca.pool,
+	})))
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go server.Serve(listener)
+	defer server.Stop()
+
+	blocks := func(tlsConfig *TLSConfig) codes.Code {
+		config := client.NewSubstreamsClientConfig(listener.Addr().String(), "", client.None, false, false)
+
+		ssClient, closeFunc, callOpts, _, err := newSubstreamsClient(config, tlsConfig)
+		require.NoError(t, err)
+		defer closeFunc()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		stream, err := ssClient.Blocks(ctx, &pbsubstreamsrpc.Request{}, callOpts...)
+		if err == nil {
+			_, err = stream.Recv()
+		}
+
+		return status.Code(err)
+	}
+
+	// No service is registered, so reaching the server is proven by an `Unimplemented` error
+	assert.Equal(t, codes.Unimplemented, blocks(&TLSConfig{
+		CAFile:     filepath.Join(dir, "ca.pem"),
+		CertFile:   filepath.Join(dir, "client.pem"),
+		KeyFile:    filepath.Join(dir, "client-key.pem"),
+		ServerName: "substreams.internal",
+	}))
+
+	assert.Equal(t, codes.Unavailable, blocks(&TLSConfig{
+		CAFile:     filepath.Join(dir, "ca.pem"),
+		ServerName: "substreams.internal",
+	}), "without client certificate")
+
+	assert.Equal(t, codes.Unavailable, blocks(&TLSConfig{
+		CAFile:   filepath.Join(dir, "ca.pem"),
+		CertFile: filepath.Join(dir, "client.pem"),
+		KeyFile:  filepath.Join(dir, "client-key.pem"),
+	}), "without server name override")
+
+	_, _, _, _, err = newSubstreamsClient(client.NewSubstreamsClientConfig(listener.Addr().String(), "", client.None, false, false), &TLSConfig{CAFile: filepath.Join(dir, "missing.pem")})
+	require.ErrorContains(t, err, "read tls CA file")
+}
+
+type testCertificate struct {
+	certificate    *x509.Certificate
+	key            *ecdsa.PrivateKey
+	tlsCertificate tls.Certificate
+	pool           *x509.CertPool
+}
+
+func newTestCertificateAuthority(t *testing.T) *testCertificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	certificate, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(certificate)
+
+	return &testCertificate{certificate: certificate, key: key, pool: pool}
+}
+
+func (ca *testCertificate) issue(t *testing.T, name string, isClient bool) *testCertificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	extKeyUsage := x509.ExtKeyUsageServerAuth
+	if isClient {
+		extKeyUsage = x509.ExtKeyUsageClientAuth
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{extKeyUsage},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.certificate, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+
+	certificate, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return &testCertificate{
+		certificate:    certificate,
+		key:            key,
+		tlsCertificate: tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key},
+	}
+}
+
+func (c *testCertificate) writePEM(t *testing.T, certPath string, keyPath ...string) {
+	t.Helper()
+
+	require.NoError(t, os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.certificate.Raw}), 0600))
+
+	if len(keyPath) > 0 {
+		der, err := x509.MarshalECPrivateKey(c.key)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(keyPath[0], pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0600))
+	}
+}
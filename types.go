@@ -2,10 +2,10 @@ package sink
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/streamingfast/bstream"
 	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
 	"go.uber.org/zap/zapcore"
 )
 
@@ -69,7 +69,8 @@ type SinkerHandler interface {
 type SinkerCompletionHandler interface {
 	// HandleBlockRangeCompletion is called when the sinker is done processing the requested range, only when
 	// the stream has correctly reached its end block. If the sinker is configured to stream live, this callback
-	// will never be called.
+	// will never be called. When multiple block range segments are requested, it's called once all segments
+	// have been processed.
 	//
 	// If the sinker terminates with an error, this callback will not be called.
 	//
@@ -79,6 +80,59 @@ type SinkerCompletionHandler interface {
 	HandleBlockRangeCompletion(ctx context.Context, cursor *Cursor) error
 }
 
+// SinkerBlockRangeSegmentCompletionHandler defines an extra interface that can be implemented on top of `SinkerHandler`
+// where the callback will be invoked each time the sinker is done processing one of the requested block range segments,
+// see [ReadBlockRanges] for how to specify multiple segments.
+type SinkerBlockRangeSegmentCompletionHandler interface {
+	// HandleBlockRangeSegmentCompletion is called when the sinker is done processing a block range segment, only when
+	// the stream has correctly reached the segment's end block. It's called for every segment, including the last
+	// one in which case it's called before [SinkerCompletionHandler.HandleBlockRangeCompletion].
+	//
+	// The handler receives the following arguments:
+	// - `ctx` is the context runtime, your handler should be minimal, so normally you shouldn't use this.
+	// - `segment` is the block range segment that was completed, with its start block resolved.
+	// - `cursor` is the cursor of the last block of the segment given to the handler.
+	HandleBlockRangeSegmentCompletion(ctx context.Context, segment *bstream.Range, cursor *Cursor) error
+}
+
+// SinkerFlushHandler defines an extra interface that can be implemented on top of `SinkerHandler` where the
+// callback will be invoked when the sinker is stopped with [Sinker.StopGracefully], once the last handler call
+// completed. Handlers batching blocks, or wrappers doing it, use it to flush their pending batch.
+type SinkerFlushHandler interface {
+	// HandleFlush is called when the sinker stops gracefully, before it terminates. If it returns an error, the
+	// sinker terminates with it instead of [ErrStoppedGracefully].
+	//
+	// The handler receives the following arguments:
+	// - `ctx` is the context runtime, your handler should be minimal, so normally you shouldn't use this.
+	// - `cursor` is the cursor of the last block handled, it's the cursor to restart from once flushed.
+	HandleFlush(ctx context.Context, cursor *Cursor) error
+}
+
+// SinkerLivenessHandler defines an extra interface that can be implemented on top of `SinkerHandler` where the
+// callbacks will be invoked when the liveness state determined by the configured [LivenessChecker] changes, see
+// [WithLivenessChecker]. They are never called if no [LivenessChecker] is configured.
+//
+// The initial state is not live, so [SinkerLivenessHandler.OnLive] is called on the first live block and
+// [SinkerLivenessHandler.OnNotLive] is only called once the chain was live before. Callbacks are called right before
+// `HandleBlockScopedData` is called for the block that changed the liveness state.
+//
+// If a callback returns an error, the [Sinker] terminates with it the same way `HandleBlockScopedData` errors do.
+type SinkerLivenessHandler interface {
+	// OnLive is called when the chain becomes live, `block` is the first live block.
+	OnLive(ctx context.Context, block *pbsubstreams.Clock) error
+
+	// OnNotLive is called when the chain is not live anymore, `block` is the first block not live.
+	OnNotLive(ctx context.Context, block *pbsubstreams.Clock) error
+}
+
+// SinkerModuleHashChangeHandler is an optional interface a [SinkerHandler] can implement to be
+// notified, before streaming starts, that the output module hash changed since the stored
+// cursor was produced, see [WithModuleHashChangePolicy]. The policy is applied once it returns,
+// an error terminates the [Sinker].
+type SinkerModuleHashChangeHandler interface {
+	HandleModuleHashChange(ctx context.Context, change *ModuleHashChange) error
+}
+
 type Cursor struct {
 	*bstream.Cursor
 }
@@ -90,7 +144,7 @@ func NewCursor(cursor string) (*Cursor, error) {
 
 	decoded, err := bstream.CursorFromOpaque(cursor)
 	if err != nil {
-		return nil, fmt.Errorf("decode %q: %w", cursor, err)
+		return nil, &CursorError{Cursor: cursor, Err: err}
 	}
 
 	return &Cursor{decoded}, nil
@@ -120,7 +174,7 @@ func (c *Cursor) Block() bstream.BlockRef {
 }
 
 func (c *Cursor) IsBlank() bool {
-	return c == nil || c == blankCursor
+	return c == nil || c == blankCursor || c.Cursor == nil
 }
 
 func (c *Cursor) IsEqualTo(other *Cursor) bool {
@@ -128,8 +182,8 @@ func (c *Cursor) IsEqualTo(other *Cursor) bool {
 		return true
 	}
 
-	// We know both are not equal, so if either side is `nil`, we are sure the other is not, so not equal
-	if !c.IsBlank() || !other.IsBlank() {
+	// At most one side is blank here, if either is, they are not equal
+	if c.IsBlank() || other.IsBlank() {
 		return false
 	}
 
@@ -169,3 +223,31 @@ func (c *Cursor) String() string {
 //
 // )
 type SubstreamsMode uint
+
+// TerminationReason is the reason why [Sinker.RunWithResult] returned, see [RunResult].
+//
+// ENUM(
+//
+//	Completed
+//	StopAtBlock
+//	StoppedGracefully
+//	Canceled
+//	BackOffExpired
+//	HandlerError
+//	Failed
+//	Shutdown
+//
+// )
+type TerminationReason uint
+
+// ModuleHashChangePolicy is the action taken when the output module hash changed since the
+// stored cursor was produced, see [WithModuleHashChangePolicy].
+//
+// ENUM(
+//
+//	Fail
+//	Warn
+//	Reset
+//
+// )
+type ModuleHashChangePolicy uint
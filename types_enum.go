@@ -73,3 +73,164 @@ func (x *SubstreamsMode) UnmarshalText(text []byte) error {
 	*x = tmp
 	return nil
 }
+
+const (
+	// TerminationReasonCompleted is a TerminationReason of type Completed.
+	TerminationReasonCompleted TerminationReason = iota
+	// TerminationReasonStopAtBlock is a TerminationReason of type StopAtBlock.
+	TerminationReasonStopAtBlock
+	// TerminationReasonStoppedGracefully is a TerminationReason of type StoppedGracefully.
+	TerminationReasonStoppedGracefully
+	// TerminationReasonCanceled is a TerminationReason of type Canceled.
+	TerminationReasonCanceled
+	// TerminationReasonBackOffExpired is a TerminationReason of type BackOffExpired.
+	TerminationReasonBackOffExpired
+	// TerminationReasonHandlerError is a TerminationReason of type HandlerError.
+	TerminationReasonHandlerError
+	// TerminationReasonFailed is a TerminationReason of type Failed.
+	TerminationReasonFailed
+	// TerminationReasonShutdown is a TerminationReason of type Shutdown.
+	TerminationReasonShutdown
+)
+
+const _TerminationReasonName = "CompletedStopAtBlockStoppedGracefullyCanceledBackOffExpiredHandlerErrorFailedShutdown"
+
+var _TerminationReasonNames = []string{
+	_TerminationReasonName[0:9],
+	_TerminationReasonName[9:20],
+	_TerminationReasonName[20:37],
+	_TerminationReasonName[37:45],
+	_TerminationReasonName[45:59],
+	_TerminationReasonName[59:71],
+	_TerminationReasonName[71:77],
+	_TerminationReasonName[77:85],
+}
+
+// TerminationReasonNames returns a list of possible string values of TerminationReason.
+func TerminationReasonNames() []string {
+	tmp := make([]string, len(_TerminationReasonNames))
+	copy(tmp, _TerminationReasonNames)
+	return tmp
+}
+
+var _TerminationReasonMap = map[TerminationReason]string{
+	TerminationReasonCompleted:         _TerminationReasonName[0:9],
+	TerminationReasonStopAtBlock:       _TerminationReasonName[9:20],
+	TerminationReasonStoppedGracefully: _TerminationReasonName[20:37],
+	TerminationReasonCanceled:          _TerminationReasonName[37:45],
+	TerminationReasonBackOffExpired:    _TerminationReasonName[45:59],
+	TerminationReasonHandlerError:      _TerminationReasonName[59:71],
+	TerminationReasonFailed:            _TerminationReasonName[71:77],
+	TerminationReasonShutdown:          _TerminationReasonName[77:85],
+}
+
+// String implements the Stringer interface.
+func (x TerminationReason) String() string {
+	if str, ok := _TerminationReasonMap[x]; ok {
+		return str
+	}
+	return fmt.Sprintf("TerminationReason(%d)", x)
+}
+
+var _TerminationReasonValue = map[string]TerminationReason{
+	_TerminationReasonName[0:9]:   TerminationReasonCompleted,
+	_TerminationReasonName[9:20]:  TerminationReasonStopAtBlock,
+	_TerminationReasonName[20:37]: TerminationReasonStoppedGracefully,
+	_TerminationReasonName[37:45]: TerminationReasonCanceled,
+	_TerminationReasonName[45:59]: TerminationReasonBackOffExpired,
+	_TerminationReasonName[59:71]: TerminationReasonHandlerError,
+	_TerminationReasonName[71:77]: TerminationReasonFailed,
+	_TerminationReasonName[77:85]: TerminationReasonShutdown,
+}
+
+// ParseTerminationReason attempts to convert a string to a TerminationReason
+func ParseTerminationReason(name string) (TerminationReason, error) {
+	if x, ok := _TerminationReasonValue[name]; ok {
+		return x, nil
+	}
+	return TerminationReason(0), fmt.Errorf("%s is not a valid TerminationReason, try [%s]", name, strings.Join(_TerminationReasonNames, ", "))
+}
+
+// MarshalText implements the text marshaller method
+func (x TerminationReason) MarshalText() ([]byte, error) {
+	return []byte(x.String()), nil
+}
+
+// UnmarshalText implements the text unmarshaller method
+func (x *TerminationReason) UnmarshalText(text []byte) error {
+	name := string(text)
+	tmp, err := ParseTerminationReason(name)
+	if err != nil {
+		return err
+	}
+	*x = tmp
+	return nil
+}
+
+const (
+	// ModuleHashChangePolicyFail is a ModuleHashChangePolicy of type Fail.
+	ModuleHashChangePolicyFail ModuleHashChangePolicy = iota
+	// ModuleHashChangePolicyWarn is a ModuleHashChangePolicy of type Warn.
+	ModuleHashChangePolicyWarn
+	// ModuleHashChangePolicyReset is a ModuleHashChangePolicy of type Reset.
+	ModuleHashChangePolicyReset
+)
+
+const _ModuleHashChangePolicyName = "FailWarnReset"
+
+var _ModuleHashChangePolicyNames = []string{
+	_ModuleHashChangePolicyName[0:4],
+	_ModuleHashChangePolicyName[4:8],
+	_ModuleHashChangePolicyName[8:13],
+}
+
+// ModuleHashChangePolicyNames returns a list of possible string values of ModuleHashChangePolicy.
+func ModuleHashChangePolicyNames() []string {
+	tmp := make([]string, len(_ModuleHashChangePolicyNames))
+	copy(tmp, _ModuleHashChangePolicyNames)
+	return tmp
+}
+
+var _ModuleHashChangePolicyMap = map[ModuleHashChangePolicy]string{
+	ModuleHashChangePolicyFail:  _ModuleHashChangePolicyName[0:4],
+	ModuleHashChangePolicyWarn:  _ModuleHashChangePolicyName[4:8],
+	ModuleHashChangePolicyReset: _ModuleHashChangePolicyName[8:13],
+}
+
+// String implements the Stringer interface.
+func (x ModuleHashChangePolicy) String() string {
+	if str, ok := _ModuleHashChangePolicyMap[x]; ok {
+		return str
+	}
+	return fmt.Sprintf("ModuleHashChangePolicy(%d)", x)
+}
+
+var _ModuleHashChangePolicyValue = map[string]ModuleHashChangePolicy{
+	_ModuleHashChangePolicyName[0:4]:  ModuleHashChangePolicyFail,
+	_ModuleHashChangePolicyName[4:8]:  ModuleHashChangePolicyWarn,
+	_ModuleHashChangePolicyName[8:13]: ModuleHashChangePolicyReset,
+}
+
+// ParseModuleHashChangePolicy attempts to convert a string to a ModuleHashChangePolicy
+func ParseModuleHashChangePolicy(name string) (ModuleHashChangePolicy, error) {
+	if x, ok := _ModuleHashChangePolicyValue[name]; ok {
+		return x, nil
+	}
+	return ModuleHashChangePolicy(0), fmt.Errorf("%s is not a valid ModuleHashChangePolicy, try [%s]", name, strings.Join(_ModuleHashChangePolicyNames, ", "))
+}
+
+// MarshalText implements the text marshaller method
+func (x ModuleHashChangePolicy) MarshalText() ([]byte, error) {
+	return []byte(x.String()), nil
+}
+
+// UnmarshalText implements the text unmarshaller method
+func (x *ModuleHashChangePolicy) UnmarshalText(text []byte) error {
+	name := string(text)
+	tmp, err := ParseModuleHashChangePolicy(name)
+	if err != nil {
+		return err
+	}
+	*x = tmp
+	return nil
+}
@@ -0,0 +1,140 @@
+package sink
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// stallTimeouts are the stall watchdog timeouts, a zero timeout disables the watchdog in the
+// corresponding mode, see [WithStallWatchdog].
+type stallTimeouts struct {
+	backprocessing time.Duration
+	live           time.Duration
+}
+
+func (t stallTimeouts) String() string {
+	if t.backprocessing <= 0 && t.live <= 0 {
+		return "disabled"
+	}
+
+	return fmt.Sprintf("backprocessing: %s, live: %s", durationOrDisabled(t.backprocessing), durationOrDisabled(t.live))
+}
+
+func durationOrDisabled(duration time.Duration) string {
+	if duration <= 0 {
+		return "disabled"
+	}
+
+	return duration.String()
+}
+
+// stallWatchdog cancels a Substreams stream when no message is received from it within a
+// timeout, the timeout used depends on whether the stream is still backprocessing or is
+// live, which is assumed as soon as a first data message is received on the stream.
+//
+// The watchdog is only armed while waiting for a message, the time spent by the handler
+// processing a message never counts toward the timeout.
+type stallWatchdog struct {
+	backprocessingTimeout time.Duration
+	liveTimeout           time.Duration
+	cancel                context.CancelCauseFunc
+
+	mu      sync.Mutex
+	timer   *time.Timer
+	live    bool
+	stalled *StreamStalledError
+}
+
+// newStallWatchdog returns the watchdog guarding the stream created with the returned
+// context, [stallWatchdog.Close] must be called once the stream is done.
+func newStallWatchdog(ctx context.Context, backprocessingTimeout, liveTimeout time.Duration) (context.Context, *stallWatchdog) {
+	streamCtx, cancel := context.WithCancelCause(ctx)
+
+	return streamCtx, &stallWatchdog{
+		backprocessingTimeout: backprocessingTimeout,
+		liveTimeout:           liveTimeout,
+		cancel:                cancel,
+	}
+}
+
+// Arm starts the timeout, the stream is canceled if [stallWatchdog.Disarm] is not called
+// before it elapses.
+func (w *stallWatchdog) Arm() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	timeout, live := w.backprocessingTimeout, w.live
+	if live {
+		timeout = w.liveTimeout
+	}
+
+	if timeout <= 0 {
+		return
+	}
+
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+
+	w.timer = time.AfterFunc(timeout, func() {
+		w.mu.Lock()
+		w.stalled = &StreamStalledError{Timeout: timeout, Live: live}
+		w.mu.Unlock()
+
+		w.cancel(w.stalled)
+	})
+}
+
+// Disarm stops the timeout started by [stallWatchdog.Arm].
+func (w *stallWatchdog) Disarm() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+}
+
+// MarkLive makes the live timeout used from now on.
+func (w *stallWatchdog) MarkLive() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.live = true
+}
+
+// Stalled returns the error describing the stall if the watchdog canceled the stream, nil
+// otherwise.
+func (w *stallWatchdog) Stalled() *StreamStalledError {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.stalled
+}
+
+func (w *stallWatchdog) Close() {
+	w.Disarm()
+	w.cancel(nil)
+}
+
+// StreamStalledError is the error, always retryable, returned when the stream was canceled
+// because no message was received from it within the configured timeout, see
+// [WithStallWatchdog].
+type StreamStalledError struct {
+	// Timeout is the timeout that elapsed.
+	Timeout time.Duration
+	// Live is true if the live timeout elapsed, false if it was the backprocessing timeout.
+	Live bool
+}
+
+func (e *StreamStalledError) Error() string {
+	mode := "backprocessing"
+	if e.Live {
+		mode = "live"
+	}
+
+	return fmt.Sprintf("no message received within %s (%s), stream considered stalled", e.Timeout, mode)
+}
@@ -0,0 +1,127 @@
+package sink
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/streamingfast/derr"
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func TestStallWatchdog(t *testing.T) {
+	progress := &pbsubstreamsrpc.Response{Message: &pbsubstreamsrpc.Response_Progress{Progress: &pbsubstreamsrpc.ModulesProgress{}}}
+	data := &pbsubstreamsrpc.Response{Message: &pbsubstreamsrpc.Response_BlockScopedData{BlockScopedData: &pbsubstreamsrpc.BlockScopedData{
+		Output: &pbsubstreamsrpc.MapModuleOutput{Name: "kv_out", MapOutput: &anypb.Any{}},
+		Clock:  &pbsubstreams.Clock{Id: "1a", Number: 1},
+	}}}
+
+	tests := []struct {
+		name           string
+		backprocessing time.Duration
+		live           time.Duration
+		handlerDelay   time.Duration
+		stream         *fakeBlocksClient
+		expectedStall  *StreamStalledError
+	}{
+		{"stalled backprocessing", 50 * time.Millisecond, 0, 0, &fakeBlocksClient{messages: []*pbsubstreamsrpc.Response{progress}}, &StreamStalledError{Timeout: 50 * time.Millisecond}},
+		{"stalled live", 0, 50 * time.Millisecond, 0, &fakeBlocksClient{messages: []*pbsubstreamsrpc.Response{progress, data}}, &StreamStalledError{Timeout: 50 * time.Millisecond, Live: true}},
+		{"slow handler not counted", 0, 50 * time.Millisecond, 150 * time.Millisecond, &fakeBlocksClient{messages: []*pbsubstreamsrpc.Response{data, data}, eof: true}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sinker := newTestSinker(t, WithStallWatchdog(tt.backprocessing, tt.live))
+			handler := NewSinkerHandlers(
+				func(ctx context.Context, data *pbsubstreamsrpc.BlockScopedData, isLive *bool, cursor *Cursor) error {
+					time.Sleep(tt.handlerDelay)
+					return nil
+				},
+				func(ctx context.Context, undoSignal *pbsubstreamsrpc.BlockUndoSignal, cursor *Cursor) error {
+					return nil
+				},
+			)
+
+			reconnectCount := testutil.ToFloat64(StallWatchdogReconnectCount.Native())
+			_, receivedMessage, err := sinker.doRequest(context.Background(), nil, &pbsubstreamsrpc.Request{}, &fakeStreamClient{tt.stream}, nil, handler)
+			assert.True(t, receivedMessage)
+
+			if tt.expectedStall == nil {
+				require.ErrorIs(t, err, io.EOF)
+				assert.Equal(t, reconnectCount, testutil.ToFloat64(StallWatchdogReconnectCount.Native()))
+				return
+			}
+
+			var retryableError *derr.RetryableError
+			require.True(t, errors.As(err, &retryableError))
+
+			var stalled *StreamStalledError
+			require.True(t, errors.As(err, &stalled))
+			assert.Equal(t, tt.expectedStall, stalled)
+			assert.Equal(t, reconnectCount+1, testutil.ToFloat64(StallWatchdogReconnectCount.Native()))
+		})
+	}
+}
+
+func Test_stallTimeouts_String(t *testing.T) {
+	assert.Equal(t, "disabled", stallTimeouts{}.String())
+	assert.Equal(t, "backprocessing: disabled, live: 30s", stallTimeouts{live: 30 * time.Second}.String())
+}
+
+func newTestSinker(t *testing.T, opts ...Option) *Sinker {
+	t.Helper()
+
+	config := NewDefaultSinkerConfig()
+	config.Endpoint = "localhost:9000"
+	config.ManifestPath = "testdata/substreams.yaml"
+	config.OutputModule = "kv_out"
+	config.UndoBufferSize = 0
+
+	sinker, err := NewFromConfig(context.Background(), config, zlog, ztracer, opts...)
+	require.NoError(t, err)
+
+	return sinker
+}
+
+type fakeStreamClient struct {
+	stream *fakeBlocksClient
+}
+
+func (c *fakeStreamClient) Blocks(ctx context.Context, _ *pbsubstreamsrpc.Request, _ ...grpc.CallOption) (pbsubstreamsrpc.Stream_BlocksClient, error) {
+	c.stream.ctx = ctx
+	return c.stream, nil
+}
+
+// fakeBlocksClient sends `messages` then returns `io.EOF` if `eof` is set, otherwise blocks
+// until the stream is canceled.
+type fakeBlocksClient struct {
+	pbsubstreamsrpc.Stream_BlocksClient
+
+	ctx      context.Context
+	messages []*pbsubstreamsrpc.Response
+	eof      bool
+}
+
+func (c *fakeBlocksClient) Recv() (*pbsubstreamsrpc.Response, error) {
+	if len(c.messages) > 0 {
+		message := c.messages[0]
+		c.messages = c.messages[1:]
+
+		return message, nil
+	}
+
+	if c.eof {
+		return nil, io.EOF
+	}
+
+	<-c.ctx.Done()
+	return nil, status.FromContextError(c.ctx.Err()).Err()
+}